@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// players who keep missing the deadline are marked AFK and sit out until they play again.
+// stay away long enough and you're out of the game.
+
+type afkRules struct {
+	after       int // missed rounds in a row before a player is AFK
+	removeAfter int // missed rounds in a row before a player is removed
+}
+
+var defaultAFKRules = newAFKRules(2)
+
+// players get removed once they've missed three times as many rounds as it took to be marked AFK
+func newAFKRules(after int) afkRules {
+	return afkRules{after: after, removeAfter: after * 3}
+}
+
+// startRoundTimer ends the round's answering (or judging) if it's still going when the time's up
+func (g *game) startRoundTimer(round *round, state roundState, timeout time.Duration) {
+	number, judgings := round.number, round.judgings
+	time.AfterFunc(timeout, func() {
+		g.stateMtx.Lock()
+		defer g.stateMtx.Unlock()
+
+		round, err := g.getCurrentRound()
+		if err != nil || round.number != number || round.state != state || round.judgings != judgings {
+			return
+		}
+
+		switch state {
+		case RoundPlaying:
+			g.playingTimedOut(round)
+		case RoundCzar:
+			g.czarTimedOut(round)
+		}
+	})
+}
+
+func (g *game) playingTimedOut(round *round) {
+	for nick := range round.players {
+		var played bool
+		for _, c := range round.cards {
+			if c.nick == nick && !c.gambled {
+				played = true
+				break
+			}
+		}
+		if !played {
+			round.missed = append(round.missed, nick)
+			delete(round.players, nick)
+			g.withdrawGamble(round, nick)
+		}
+	}
+
+	if len(round.missed) > 0 {
+		g.sendMsg(fmt.Sprintf("Time's up! %s didn't play.", strings.Join(round.missed, ", ")))
+	}
+
+	if g.countAnswers(round) == 0 {
+		g.sendMsg("Nobody played anything. Moving on.")
+		g.endRound(round, nil)
+		return
+	}
+
+	g.checkIfRoundOver(round)
+}
+
+func (g *game) czarTimedOut(round *round) {
+	judge := round.czar
+	if s, ok := g.scoring.(*survivalScoring); ok {
+		judge = s.nextEliminator(g, round)
+	}
+
+	if judge != "" {
+		round.missed = append(round.missed, judge)
+		g.sendMsg(fmt.Sprintf("Time's up! %s fell asleep.", judge))
+	}
+
+	g.endRound(round, nil)
+}
+
+// updateParticipation counts missed rounds after each round, marks players AFK, and removes
+// anyone who's been gone too long
+func (g *game) updateParticipation(round *round) {
+	var remove []string
+	for _, p := range g.players {
+		if p.suspended {
+			continue
+		}
+
+		switch {
+		case containsNick(round.missed, p.nick):
+			p.missedRounds++
+		case p.afk:
+			// sitting out still counts against you
+			p.missedRounds++
+		case p.nick == round.czar:
+			p.missedRounds = 0
+		default:
+			if _, ok := round.players[p.nick]; ok {
+				p.missedRounds = 0
+			}
+		}
+
+		switch {
+		case p.missedRounds >= g.afk.removeAfter:
+			remove = append(remove, p.nick)
+		case p.missedRounds == g.afk.removeAfter-1 && p.afk:
+			g.sendMsg(fmt.Sprintf("%s, last chance. Type !back or you're out of the game after the next round.", p.nick))
+		case p.missedRounds >= g.afk.after && !p.afk:
+			p.afk = true
+			g.sendMsg(fmt.Sprintf("%s missed %d rounds in a row and is AFK. Sitting out until you type !back or !play.", p.nick, p.missedRounds))
+		}
+	}
+
+	for _, nick := range remove {
+		g.dropPlayer(nick, fmt.Sprintf("%s has been AFK for %d rounds and is out of the game.", nick, g.afk.removeAfter))
+	}
+}
+
+// back brings an AFK player into the next round
+func (g *game) back(nick string) error {
+	player := g.getPlayer(nick)
+	if player == nil {
+		return fmt.Errorf("%q isn't playing", nick)
+	}
+
+	if !player.afk {
+		return nil
+	}
+
+	player.afk = false
+	player.missedRounds = 0
+	g.sendMsg(fmt.Sprintf("Welcome back %s! You're in from the next round.", nick))
+
+	return nil
+}
@@ -12,17 +12,29 @@ import (
 type bot struct {
 	botCfg         *botConfig
 	irc            *ircClient
+	store          *dataStore
+	users          *userDirectory
 	games          map[string]*game
 	gamesMtx       sync.Mutex
 	publicMessages chan ircPRIVMSG
+	whispers       chan ircWHISPER
 	joins          chan ircJOIN
 	parts          chan ircPART
 	exit           chan struct{}
 }
 
 func (b *bot) Start() error {
+	store, err := newDataStore(b.botCfg.dataDir)
+	if err != nil {
+		return err
+	}
+
+	b.store = store
+	debugMode = b.botCfg.debug
+	b.users = newUserDirectory()
 	b.games = make(map[string]*game)
 	b.publicMessages = make(chan ircPRIVMSG)
+	b.whispers = make(chan ircWHISPER)
 	b.joins = make(chan ircJOIN)
 	b.parts = make(chan ircPART)
 
@@ -45,6 +57,10 @@ loop:
 			if err := b.processPRIVMSG(msg); err != nil {
 				log.Println(err)
 			}
+		case whisper := <-b.whispers:
+			if err := b.processWHISPER(whisper); err != nil {
+				log.Println(err)
+			}
 		case join := <-b.joins:
 			b.processJOIN(join)
 		case part := <-b.parts:
@@ -57,36 +73,70 @@ loop:
 
 func (b *bot) processPRIVMSG(msg ircPRIVMSG) error {
 	cmds := []string{
-		"!start",  // start new game
-		"!stop",   // stop game
-		"!pause",  // pause game
-		"!resume", // resume game
-		"!join",   // join in-progress game
-		"!quit",   // quit game
-		"!cards",  // show cards you have in your hand
-		"!play",   // play a card or cards
-		"!winner", // pick a winner (czar)
-		"!points", // show players' awesome points
-		"!list",   // list players in current game
-		"!status", // show current status (waiting for players to play)
-		"!gamble", // game an awesome point and play 2 (or 4) cards
-		"!help",   // show help
-		"!pick",   // same as !play or !winner
+		"!start",       // start new game
+		"!stop",        // stop game
+		"!pause",       // pause game
+		"!resume",      // resume game
+		"!join",        // join in-progress game
+		"!quit",        // quit game
+		"!cards",       // show cards you have in your hand
+		"!play",        // play a card or cards
+		"!winner",      // pick a winner (czar)
+		"!eliminate",   // eliminate an answer (house rule)
+		"!points",      // show players' awesome points
+		"!list",        // list players in current game
+		"!status",      // show current status (waiting for players to play)
+		"!gamble",      // game an awesome point and play 2 (or 4) cards
+		"!help",        // show help
+		"!pick",        // same as !play or !winner
+		"!reboot",      // spend an awesome point to get a new hand (house rule)
+		"!vote",        // vote for the best answer (house rule)
+		"!never",       // discard a card you don't get (house rule)
+		"!defaults",    // show or set the channel's game options (broadcaster)
+		"!ready",       // ready to start (lobby)
+		"!unready",     // not ready yet (lobby)
+		"!czarorder",   // show who's czar next
+		"!spectate",    // watch the game without playing
+		"!back",        // back from being AFK
+		"!kick",        // kick a player (moderators) or vote to kick them (players)
+		"!ban",         // ban a nick from the channel's games (moderators)
+		"!unban",       // lift a ban (moderators)
+		"!undo",        // take back the winner pick (czar or moderators)
+		"!profile",     // show a player's all-time stats
+		"!leaderboard", // show the best players in the channel or everywhere
+		"!rank",        // show a player's rating this season
+		"!quote",       // show a winning answer from the Hall of Fame
+		"!fame",        // show the most upvoted winning answers
+		"!upvote",      // upvote the latest winning answer
+		"!blockcard",   // keep a card out of the channel's games (moderators)
 	}
 
+	b.users.remember(msg.Nick, msg.Tags["user-id"])
+
 	b.gamesMtx.Lock()
 	game, ok := b.games[msg.Channel]
 	b.gamesMtx.Unlock()
 
+	if ok {
+		game.stateMtx.Lock()
+		defer game.stateMtx.Unlock()
+	}
+
 	for _, cmd := range cmds {
 		if strings.HasPrefix(msg.Message, cmd) {
 			switch cmd {
 			case "!start":
+				if b.turnAwayBanned(msg.Channel, msg.Nick) {
+					return nil
+				}
 				if err := b.startGame(msg.Channel, msg.Nick, msg.Message); err != nil {
 					log.Println(err)
 					return err
 				}
 			case "!join":
+				if b.turnAwayBanned(msg.Channel, msg.Nick) {
+					return nil
+				}
 				if !ok {
 					if err := b.startGame(msg.Channel, msg.Nick, msg.Message); err != nil {
 						log.Println(err)
@@ -95,24 +145,206 @@ func (b *bot) processPRIVMSG(msg ircPRIVMSG) error {
 				} else {
 					game.join(msg.Nick)
 				}
+			case "!quit":
+				if !ok {
+					b.irc.Say(msg.Channel, "No game in progress. !start to start a game")
+					return nil
+				}
+				if err := game.quitPlayer(msg.Nick); err != nil {
+					log.Println(err)
+					return nil
+				}
+			case "!kick":
+				if !ok {
+					b.irc.Say(msg.Channel, "No game in progress. !start to start a game")
+					return nil
+				}
+				args := strings.Fields(msg.Message)[1:]
+				if len(args) != 1 {
+					b.irc.Say(msg.Channel, "Type !kick nick")
+					return nil
+				}
+				var err error
+				if msg.isModerator() {
+					err = game.kick(kickTarget(args[0]), fmt.Sprintf("by %s", msg.Nick))
+				} else {
+					err = game.voteKick(msg.Nick, kickTarget(args[0]))
+				}
+				if err != nil {
+					log.Println(err)
+					return nil
+				}
+			case "!ban", "!unban":
+				if !msg.isModerator() {
+					b.irc.Say(msg.Channel, fmt.Sprintf("%s, only moderators can ban players.", msg.Nick))
+					return nil
+				}
+				var err error
+				if cmd == "!ban" {
+					err = b.banPlayer(msg.Channel, msg.Nick, strings.Fields(msg.Message)[1:], game)
+				} else {
+					err = b.unbanPlayer(msg.Channel, strings.Fields(msg.Message)[1:])
+				}
+				if err != nil {
+					log.Println(err)
+					return err
+				}
+			case "!blockcard":
+				if !msg.isModerator() {
+					b.irc.Say(msg.Channel, fmt.Sprintf("%s, only moderators can block cards.", msg.Nick))
+					return nil
+				}
+				if err := b.blockCard(msg.Channel, msg.Nick, strings.Fields(msg.Message)[1:], game); err != nil {
+					log.Println(err)
+					return err
+				}
+			case "!profile":
+				if err := b.showProfile(msg.Channel, msg.Nick, strings.Fields(msg.Message)[1:]); err != nil {
+					log.Println(err)
+					return err
+				}
+			case "!leaderboard":
+				if err := b.showLeaderboard(msg.Channel, strings.Fields(msg.Message)[1:]); err != nil {
+					log.Println(err)
+					return err
+				}
+			case "!quote":
+				if err := b.showQuote(msg.Channel, strings.Fields(msg.Message)[1:]); err != nil {
+					log.Println(err)
+					return err
+				}
+			case "!fame":
+				if err := b.showFame(msg.Channel); err != nil {
+					log.Println(err)
+					return err
+				}
+			case "!upvote":
+				if err := b.upvoteQuote(msg.Channel, msg.Nick); err != nil {
+					log.Println(err)
+					return err
+				}
+			case "!rank":
+				if err := b.showRank(msg.Channel, msg.Nick, strings.Fields(msg.Message)[1:]); err != nil {
+					log.Println(err)
+					return err
+				}
+			case "!undo":
+				if !ok {
+					b.irc.Say(msg.Channel, "No game in progress. !start to start a game")
+					return nil
+				}
+				if err := game.undo(msg.Nick, msg.isModerator()); err != nil {
+					log.Println(err)
+					return nil
+				}
+			case "!back":
+				if !ok {
+					b.irc.Say(msg.Channel, "No game in progress. !start to start a game")
+					return nil
+				}
+				if err := game.back(msg.Nick); err != nil {
+					log.Println(err)
+					return nil
+				}
+			case "!spectate":
+				if !ok {
+					b.irc.Say(msg.Channel, "No game in progress. !start to start a game")
+					return nil
+				}
+				if err := game.spectate(msg.Nick); err != nil {
+					log.Println(err)
+					return nil
+				}
+			case "!status":
+				if !ok {
+					b.irc.Say(msg.Channel, "No game in progress. !start to start a game")
+					return nil
+				}
+				if err := game.status(msg.Nick); err != nil {
+					log.Println(err)
+					return nil
+				}
+			case "!czarorder":
+				if !ok {
+					b.irc.Say(msg.Channel, "No game in progress. !start to start a game")
+					return nil
+				}
+				game.showCzarOrder()
+			case "!ready", "!unready":
+				if !ok {
+					b.irc.Say(msg.Channel, "No game in progress. !start to start a game")
+					return nil
+				}
+				if err := game.setReady(msg.Nick, cmd == "!ready"); err != nil {
+					log.Println(err)
+					return nil
+				}
+			case "!defaults":
+				if err := b.setChannelDefaults(msg.Channel, msg.Nick, strings.Fields(msg.Message)[1:]); err != nil {
+					log.Println(err)
+					return err
+				}
+			case "!gamble":
+				if !ok {
+					b.irc.Say(msg.Channel, "No game in progress. !start to start a game")
+					return nil
+				}
+				nums, err := b.extractNumbers(msg.Message)
+				if err != nil {
+					log.Println(err)
+					return nil
+				}
+				if err := game.gamble(msg.Nick, nums); err != nil {
+					log.Println(err)
+					return nil
+				}
 			case "!play":
 				if !ok {
 					b.irc.Say(msg.Channel, "No game in progress. !start to start a game")
 					return nil
 				}
-				var nums []int
+				answers := 1
+				if round, err := game.getCurrentRound(); err == nil {
+					answers = round.question.NumAnswers
+				}
+				nums, writeIn, err := b.extractWriteIn(msg.Message, answers)
+				if err != nil {
+					log.Println(err)
+					return err
+				}
+				if err := game.play(msg.Nick, nums, writeIn); err != nil {
+					log.Println(err)
+					return nil
+				}
+			case "!winner", "!eliminate":
+				if !ok {
+					b.irc.Say(msg.Channel, "No game in progress. !start to start a game")
+					return nil
+				}
+				nums, err := b.extractNumbers(msg.Message)
+				if err != nil {
+					log.Println(err)
+					return nil
+				}
+				if err := game.winner(msg.Nick, nums); err != nil {
+					log.Println(err)
+					return nil
+				}
+			case "!vote":
+				if !ok {
+					b.irc.Say(msg.Channel, "No game in progress. !start to start a game")
+					return nil
+				}
 				num, err := b.extractNumber(msg.Message)
 				if err != nil {
-					nums, err = b.extractNumbers(msg.Message)
-					if err != nil {
-						log.Println(err)
-						return err
-					}
-				} else {
-					nums = []int{num}
+					log.Println(err)
+					return nil
+				}
+				if err := game.vote(msg.Nick, num); err != nil {
+					log.Println(err)
+					return nil
 				}
-				game.play(msg.Nick, nums)
-			case "!winner":
+			case "!never":
 				if !ok {
 					b.irc.Say(msg.Channel, "No game in progress. !start to start a game")
 					return nil
@@ -122,7 +354,19 @@ func (b *bot) processPRIVMSG(msg ircPRIVMSG) error {
 					log.Println(err)
 					return nil
 				}
-				game.winner(msg.Nick, num)
+				if err := game.neverHaveIEver(msg.Nick, num); err != nil {
+					log.Println(err)
+					return nil
+				}
+			case "!reboot":
+				if !ok {
+					b.irc.Say(msg.Channel, "No game in progress. !start to start a game")
+					return nil
+				}
+				if err := game.rebootHand(msg.Nick); err != nil {
+					log.Println(err)
+					return nil
+				}
 			}
 		}
 	}
@@ -142,25 +386,69 @@ func (b *bot) extractNumber(message string) (int, error) {
 }
 
 func (b *bot) extractNumbers(message string) ([]int, error) {
-	regex := regexp.MustCompile("^[!]\\w+ (?P<number1>\\d+) (?P<number2>\\d+)$")
+	regex := regexp.MustCompile("^[!]\\w+((?: \\d+)+)$")
+	found := regex.FindAllStringSubmatch(message, -1)
+	if found == nil || len(found) != 1 || len(found[0]) != 2 {
+		return nil, fmt.Errorf("couldn't extract digits from %q", message)
+	}
+
+	var nums []int
+	for _, number := range strings.Fields(found[0][1]) {
+		num, err := strconv.Atoi(number)
+		if err != nil {
+			return nil, err
+		}
+		nums = append(nums, num)
+	}
+
+	return nums, nil
+}
+
+// extractWriteIn reads the card numbers for a question with the given number of answers, then
+// the text for a blank card, e.g. "!play 3 my answer". the write-in can start with a number,
+// "!play 3 2 girls 1 cup" is card 3 with "2 girls 1 cup" on it.
+func (b *bot) extractWriteIn(message string, answers int) ([]int, string, error) {
+	regex := regexp.MustCompile(fmt.Sprintf("^[!]\\w+((?: \\d+){1,%d})(?: (?P<text>.+))?$", answers))
 	found := regex.FindAllStringSubmatch(message, -1)
 	if found == nil || len(found) != 1 || len(found[0]) != 3 {
-		return nil, fmt.Errorf("couldn't extract 2 digits from %q", message)
+		return nil, "", fmt.Errorf("couldn't extract digits from %q", message)
 	}
 
-	number1 := found[0][1]
-	num1, err := strconv.Atoi(number1)
-	if err != nil {
-		return nil, err
+	var nums []int
+	for _, number := range strings.Fields(found[0][1]) {
+		num, err := strconv.Atoi(number)
+		if err != nil {
+			return nil, "", err
+		}
+		nums = append(nums, num)
 	}
 
-	number2 := found[0][2]
-	num2, err := strconv.Atoi(number2)
-	if err != nil {
-		return nil, err
+	return nums, strings.TrimSpace(found[0][2]), nil
+}
+
+// processWHISPER handles a command whispered to the bot as if it was typed in the
+// channel of the game the player (or spectator) is in
+func (b *bot) processWHISPER(whisper ircWHISPER) error {
+	var channel string
+	b.gamesMtx.Lock()
+	for c, game := range b.games {
+		game.stateMtx.Lock()
+		inGame := game.getPlayer(whisper.Nick) != nil || containsNick(game.spectators, whisper.Nick)
+		game.stateMtx.Unlock()
+		if inGame {
+			channel = c
+			break
+		}
+	}
+	b.gamesMtx.Unlock()
+
+	if channel == "" {
+		return nil
 	}
 
-	return []int{num1, num2}, nil
+	msg := ircPRIVMSG{ircUserAction: whisper.ircUserAction, Message: whisper.Message}
+	msg.Channel = channel
+	return b.processPRIVMSG(msg)
 }
 
 func (b *bot) processJOIN(join ircJOIN) error {
@@ -172,6 +460,9 @@ func (b *bot) processJOIN(join ircJOIN) error {
 		return nil
 	}
 
+	game.stateMtx.Lock()
+	defer game.stateMtx.Unlock()
+
 	return game.resumePlayer(join.Nick)
 }
 
@@ -184,26 +475,37 @@ func (b *bot) processPART(part ircPART) error {
 		return nil
 	}
 
+	game.stateMtx.Lock()
+	defer game.stateMtx.Unlock()
+
 	return game.suspendPlayer(part.Nick)
 }
 
 func (b *bot) startGame(channel, gameStarter, fullMessage string) error {
 	b.gamesMtx.Lock()
-	defer b.gamesMtx.Unlock()
-
 	game, ok := b.games[channel]
+	b.gamesMtx.Unlock()
+
 	if ok {
-		// TODO: check if user is already playing, if not add them to the current game
-		b.irc.Say(channel, "Game already in progress. !join to join game")
+		// the game starter can skip the lobby's wait by typing !start again
+		return game.forceStart(gameStarter)
+	}
+
+	// anything after the command is an option, e.g. "!start points=8 rules=rando"
+	opts, err := b.loadGameOptions(channel, strings.Fields(fullMessage)[1:])
+	if err != nil {
+		b.irc.Say(channel, err.Error())
 		return nil
 	}
 
-	var err error
-	if game, err = newGame(gameStarter, 5); err != nil {
+	if game, err = newGame(channel, gameStarter, opts, b.store, b.users); err != nil {
+		b.irc.Say(channel, fmt.Sprintf("Couldn't start the game: %v", err))
 		return err
 	}
 
+	b.gamesMtx.Lock()
 	b.games[channel] = game
+	b.gamesMtx.Unlock()
 
 	go b.gameMessageLoop(game, channel)
 
@@ -218,6 +520,28 @@ loop:
 			b.irc.Say(channel, msg)
 		case whisper := <-game.whispers:
 			b.irc.Whisper(channel, whisper.nick, whisper.message)
+		case <-game.done:
+			b.gamesMtx.Lock()
+			if b.games[channel] == game {
+				delete(b.games, channel)
+			}
+			b.gamesMtx.Unlock()
+
+			// say whatever the game had left to say
+		drain:
+			for {
+				select {
+				case msg := <-game.messages:
+					b.irc.Say(channel, msg)
+				case whisper := <-game.whispers:
+					b.irc.Whisper(channel, whisper.nick, whisper.message)
+				default:
+					break drain
+				}
+			}
+
+			b.postRecap(channel, game)
+			break loop
 		case <-b.exit:
 			b.gamesMtx.Lock()
 			delete(b.games, channel)
@@ -239,8 +563,10 @@ func (b *bot) connectIRC() error {
 		Nick:                 b.botCfg.nick,
 		ServerPassword:       b.botCfg.serverPassword,
 		PublicMessages:       b.publicMessages,
+		Whispers:             b.whispers,
 		Joins:                b.joins,
 		Parts:                b.parts,
+		Filter:               newOutboundFilter(b.store),
 	}
 
 	if err = irc.Connect(); err != nil {
@@ -12,11 +12,17 @@ type botConfig struct {
 	nick           string
 	channels       []string
 	serverPassword string
+	dataDir        string
+	recapDir       string
+	debug          bool
 }
 
 func parseArgs(args []string) (*botConfig, error) {
 	flagSet := &flag.FlagSet{}
 	nick := flagSet.String("nick", "", "bot's nick")
+	dataDir := flagSet.String("data-dir", "data", "directory for saved games, stats and channel settings")
+	recapDir := flagSet.String("recap-dir", "recaps", "directory for end of game recaps")
+	debug := flagSet.Bool("debug", false, "check the deck for lost and duplicated cards every round")
 	channels := StringArray{}
 	flagSet.Var(&channels, "channel", "channel to join (can be specified multiple times)")
 	err := flagSet.Parse(args)
@@ -44,5 +50,8 @@ func parseArgs(args []string) (*botConfig, error) {
 		nick:           *nick,
 		channels:       channels,
 		serverPassword: serverPassword,
+		dataDir:        *dataDir,
+		recapDir:       *recapDir,
+		debug:          *debug,
 	}, nil
 }
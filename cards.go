@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
 	"html"
 	"io/ioutil"
 	"net/http"
@@ -10,10 +11,14 @@ import (
 
 const cardsURL = "https://raw.githubusercontent.com/samurailink3/hangouts-against-humanity/master/source/data/cards.js"
 
+// fetchCards is where new games get their cards, tests deal their own
+var fetchCards = getCardsFromWeb
+
 type card struct {
 	ID         int    `json:"id"`
 	Text       string `json:"text"`
 	NumAnswers int    `json:"numAnswers"`
+	Expansion  string `json:"-"`
 }
 
 // holds the json from the url above
@@ -34,6 +39,7 @@ type questionCard struct {
 
 type answerCard struct {
 	card
+	blank bool // players write their own answer on it
 }
 
 func getCardsFromWeb() (*cardBox, error) {
@@ -71,15 +77,49 @@ func getCardsFromWeb() (*cardBox, error) {
 		c.Text = strings.Replace(c.Text, "<u>", "", -1)
 		c.Text = strings.Replace(c.Text, "</u>", "", -1)
 		c.Text = strings.Replace(c.Text, "  ", " ", -1)
+		c.card.Expansion = c.Expansion
 
 		switch c.CardType {
 		case "Q":
 			questions = append(questions, questionCard{c.card})
 		case "A":
 			c.card.Text = strings.TrimRight(c.card.Text, ".")
-			answers = append(answers, answerCard{c.card})
+			answers = append(answers, answerCard{card: c.card})
 		}
 	}
 
 	return &cardBox{questions: questions, answers: answers}, nil
 }
+
+// filterDecks keeps only the cards from the given expansions. no expansions means keep everything.
+func (b *cardBox) filterDecks(decks []string) (*cardBox, error) {
+	if len(decks) == 0 {
+		return b, nil
+	}
+
+	filtered := &cardBox{}
+	for _, deck := range decks {
+		var found bool
+		for _, q := range b.questions {
+			if strings.EqualFold(q.Expansion, deck) {
+				filtered.questions = append(filtered.questions, q)
+				found = true
+			}
+		}
+		for _, a := range b.answers {
+			if strings.EqualFold(a.Expansion, deck) {
+				filtered.answers = append(filtered.answers, a)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("there's no %q deck", deck)
+		}
+	}
+
+	if len(filtered.questions) == 0 || len(filtered.answers) == 0 {
+		return nil, fmt.Errorf("decks %s don't have both question and answer cards", strings.Join(decks, ", "))
+	}
+
+	return filtered, nil
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// every game counts what happened to each card: how often it was dealt, played, won, thrown away
+// with a reboot or !never, or ignored by the audience. `go-cah stats cards` reports on it and
+// can prune the dead cards out of a channel's deck.
+const (
+	cardStatsFile = "card-stats.json"
+	// one card key per line, these cards are left out of the channel's games
+	prunedCardsFile = "pruned-cards.txt"
+
+	answerKind   = "a"
+	questionKind = "q"
+)
+
+type cardStats struct {
+	Kind       string `json:"kind"`
+	ID         int    `json:"id"`
+	Expansion  string `json:"expansion"`
+	Text       string `json:"text"`
+	Dealt      int    `json:"dealt"`
+	Played     int    `json:"played,omitempty"`
+	Won        int    `json:"won,omitempty"`
+	Mulliganed int    `json:"mulliganed,omitempty"` // rebooted or !never'd away
+	LowVotes   int    `json:"low_votes,omitempty"`  // played in a round the audience voted in, got no votes
+	Flat       int    `json:"flat,omitempty"`       // questions only, rounds nobody won
+}
+
+// cardKey is how a card's stats are stored, like "a:Base:123"
+func cardKey(kind string, c card) string {
+	return fmt.Sprintf("%s:%s:%d", kind, c.Expansion, c.ID)
+}
+
+func (s *cardStats) add(other *cardStats) {
+	s.Dealt += other.Dealt
+	s.Played += other.Played
+	s.Won += other.Won
+	s.Mulliganed += other.Mulliganed
+	s.LowVotes += other.LowVotes
+	s.Flat += other.Flat
+}
+
+func (s *cardStats) winRate() float64 {
+	if s.Played == 0 {
+		return 0
+	}
+	return float64(s.Won) / float64(s.Played)
+}
+
+// dead cards have had plenty of chances and nobody wants them
+func (s *cardStats) dead(minDealt int) bool {
+	if s.Kind == questionKind {
+		return s.Dealt >= (minDealt+3)/4 && s.Flat*2 >= s.Dealt
+	}
+	return s.Dealt >= minDealt && s.Won == 0 && (s.Played*4 < s.Dealt || s.Mulliganed*2 >= s.Dealt)
+}
+
+// tallyCard is the card's stats for this game. blanks and the haiku card aren't counted.
+func (g *game) tallyCard(kind string, c card) *cardStats {
+	if c.ID < 0 {
+		return &cardStats{}
+	}
+
+	if g.cardTally == nil {
+		g.cardTally = make(map[string]*cardStats)
+	}
+
+	key := cardKey(kind, c)
+	if g.cardTally[key] == nil {
+		g.cardTally[key] = &cardStats{Kind: kind, ID: c.ID, Expansion: c.Expansion, Text: c.Text}
+	}
+	return g.cardTally[key]
+}
+
+// tallyRound counts what got played and what won once the round's over
+func (g *game) tallyRound(round *round) {
+	if len(round.awards) == 0 {
+		g.tallyCard(questionKind, round.question.card).Flat++
+	}
+
+	votes := make(map[int]int)
+	for _, i := range round.votes {
+		votes[i]++
+	}
+
+	for i, c := range round.cards {
+		var won bool
+		for _, award := range round.awards {
+			if award.cardIndex == i {
+				won = true
+			}
+		}
+
+		for _, answer := range c.cards {
+			s := g.tallyCard(answerKind, answer.card)
+			s.Played++
+			if won {
+				s.Won++
+			}
+			if len(round.votes) > 0 && votes[i] == 0 {
+				s.LowVotes++
+			}
+		}
+	}
+}
+
+// recordCardStats adds the game's counts to the channel's card stats
+func (g *game) recordCardStats() {
+	if g.store == nil || len(g.cardTally) == 0 {
+		return
+	}
+
+	var stats map[string]*cardStats
+	err := g.store.update(channelFile(g.channel, cardStatsFile), &stats, func() error {
+		if stats == nil {
+			stats = make(map[string]*cardStats)
+		}
+		for key, s := range g.cardTally {
+			if stats[key] == nil {
+				stats[key] = &cardStats{Kind: s.Kind, ID: s.ID, Expansion: s.Expansion, Text: s.Text}
+			}
+			stats[key].add(s)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// prune takes the cards with the given keys out of the box, for pruned and blocked cards
+func (b *cardBox) prune(keys []string) (*cardBox, error) {
+	if len(keys) == 0 {
+		return b, nil
+	}
+
+	pruned := make(map[string]bool)
+	for _, line := range keys {
+		// anything after the key is a note
+		pruned[strings.Fields(line)[0]] = true
+	}
+
+	filtered := &cardBox{}
+	for _, q := range b.questions {
+		if !pruned[cardKey(questionKind, q.card)] {
+			filtered.questions = append(filtered.questions, q)
+		}
+	}
+	for _, a := range b.answers {
+		if !pruned[cardKey(answerKind, a.card)] {
+			filtered.answers = append(filtered.answers, a)
+		}
+	}
+
+	if len(filtered.questions) == 0 || len(filtered.answers) == 0 {
+		return nil, errors.New("the channel pruned or blocked every card, check " + prunedCardsFile + " and " + blockedCardsFile)
+	}
+
+	return filtered, nil
+}
+
+// runStats is `go-cah stats cards [--data-dir=data] [--channel=#name] [--min-dealt=20] [--top=10] [--prune]`
+func runStats(args []string) error {
+	if len(args) == 0 || args[0] != "cards" {
+		return errors.New("usage: go-cah stats cards [--data-dir=data] [--channel=#name] [--min-dealt=20] [--top=10] [--prune]")
+	}
+
+	flagSet := flag.NewFlagSet("stats cards", flag.ContinueOnError)
+	dataDir := flagSet.String("data-dir", "data", "directory for saved games, stats and channel settings")
+	channel := flagSet.String("channel", "", "only this channel's stats (all channels if empty)")
+	minDealt := flagSet.Int("min-dealt", 20, "times an answer has to be dealt before it can be called dead")
+	top := flagSet.Int("top", 10, "how many of the best and worst cards to show")
+	prune := flagSet.Bool("prune", false, "leave the dead cards out of the channel's games (needs --channel)")
+	if err := flagSet.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	if *prune && *channel == "" {
+		return errors.New("--prune needs a --channel")
+	}
+	if *channel != "" && !strings.HasPrefix(*channel, "#") {
+		*channel = "#" + *channel
+	}
+
+	store, err := newDataStore(*dataDir)
+	if err != nil {
+		return err
+	}
+
+	files := []string{channelFile(*channel, cardStatsFile)}
+	if *channel == "" {
+		matches, err := filepath.Glob(filepath.Join(*dataDir, "*", cardStatsFile))
+		if err != nil {
+			return err
+		}
+		files = nil
+		for _, m := range matches {
+			rel, err := filepath.Rel(*dataDir, m)
+			if err != nil {
+				return err
+			}
+			files = append(files, rel)
+		}
+	}
+
+	all := make(map[string]*cardStats)
+	for _, file := range files {
+		var stats map[string]*cardStats
+		if err := store.load(file, &stats); err != nil {
+			return err
+		}
+		for key, s := range stats {
+			if all[key] == nil {
+				all[key] = &cardStats{Kind: s.Kind, ID: s.ID, Expansion: s.Expansion, Text: s.Text}
+			}
+			all[key].add(s)
+		}
+	}
+
+	if len(all) == 0 {
+		fmt.Println("no card stats yet, go play some games")
+		return nil
+	}
+
+	var answers, questions, dead []*cardStats
+	var deadKeys []string
+	for key, s := range all {
+		if s.Kind == questionKind {
+			questions = append(questions, s)
+		} else {
+			answers = append(answers, s)
+		}
+		if s.dead(*minDealt) {
+			dead = append(dead, s)
+			deadKeys = append(deadKeys, key)
+		}
+	}
+
+	sort.Slice(answers, func(i, j int) bool {
+		if answers[i].winRate() != answers[j].winRate() {
+			return answers[i].winRate() > answers[j].winRate()
+		}
+		return answers[i].Won > answers[j].Won
+	})
+	sort.Slice(questions, func(i, j int) bool {
+		return questions[i].Flat*questions[j].Dealt > questions[j].Flat*questions[i].Dealt
+	})
+	sort.Slice(dead, func(i, j int) bool { return dead[i].Dealt > dead[j].Dealt })
+	sort.Strings(deadKeys)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	printCards := func(title string, cards []*cardStats) {
+		fmt.Fprintf(w, "\n%s\n", title)
+		fmt.Fprintln(w, "deck\tid\tdealt\tplayed\twon\twin rate\tmulliganed\tno votes\tflat\ttext")
+		for i, s := range cards {
+			if i == *top {
+				break
+			}
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%.0f%%\t%d\t%d\t%d\t%s\n",
+				s.Expansion, s.ID, s.Dealt, s.Played, s.Won, s.winRate()*100, s.Mulliganed, s.LowVotes, s.Flat, s.Text)
+		}
+	}
+
+	printCards("answers that win the most", answers)
+	printCards("questions that fall flat", questions)
+	printCards(fmt.Sprintf("dead cards (%d)", len(dead)), dead)
+	w.Flush()
+
+	if !*prune {
+		return nil
+	}
+
+	var lines []string
+	lines = append(lines, "# dead cards found by go-cah stats cards --prune, running it again rewrites this file")
+	for _, key := range deadKeys {
+		lines = append(lines, fmt.Sprintf("%s # %s", key, all[key].Text))
+	}
+	name := filepath.Join(*dataDir, channelFile(*channel, prunedCardsFile))
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(name, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		return err
+	}
+	fmt.Printf("\npruned %d cards from %s's games, see %s\n", len(deadKeys), *channel, name)
+
+	return nil
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// cards get tagged by what's in them, and every tag has a rating. a game only deals cards rated
+// at or below its rating=, and the channel's !defaults rating is as far as a !start can go.
+// moderators can !blockcard anything that still gets through.
+type contentRating int
+
+const (
+	RatingFamily contentRating = iota
+	RatingTeen
+	RatingMature
+)
+
+var contentRatingNames = []string{"family", "teen", "mature"}
+
+func (r contentRating) String() string {
+	return contentRatingNames[r]
+}
+
+func parseContentRating(name string) (contentRating, error) {
+	for i, n := range contentRatingNames {
+		if strings.EqualFold(name, n) {
+			return contentRating(i), nil
+		}
+	}
+	return RatingMature, fmt.Errorf("rating needs to be %s, not %q", strings.Join(contentRatingNames, ", "), name)
+}
+
+type contentCategory struct {
+	name   string
+	rating contentRating // the lowest rating the category is allowed at
+	// whole words. a trailing * matches anything starting with it, so those are only for stems
+	// no everyday word starts with ("stab*" would catch stable).
+	words []string
+}
+
+// not a complete list, just enough to catch most of the deck. card-tags.txt fixes the rest.
+var contentCategories = []contentCategory{
+	{name: "sex", rating: RatingMature, words: []string{
+		"sex", "sexes", "sexy", "sexier", "sexiest", "sexual*", "sexting", "penis*", "vagina*",
+		"masturbat*", "porn*", "orgasm*", "erection*", "naked", "nude*", "boob*", "tits", "titties",
+		"dick", "dicks", "dickhead*", "cock", "cocks", "anal", "anus", "dildo*", "horny", "condom",
+		"condoms", "genital*", "testicle*", "sperm", "semen", "ejaculat*", "pubic", "pube", "pubes",
+		"nipple*", "bukkake", "foreskin", "virgin", "virgins", "virginity", "prostitut*", "hooker*",
+		"stripper*", "bondage", "erotic*", "fetish*", "incest*", "pedophil*", "molest*", "rape",
+		"raping", "scrotum", "clitoris", "labia", "lube", "viagra", "hentai", "threesome*",
+		"blowjob*", "handjob*", "sodom*", "jerking", "fap", "fapping", "queef*", "smegma", "spooge",
+		"cum", "cumming", "butt", "butthole",
+	}},
+	{name: "offensive", rating: RatingMature, words: []string{
+		"nigger*", "nigga*", "fag", "fags", "faggot*", "retard", "retards", "retarded", "tranny",
+		"hitler*", "nazi*", "holocaust", "kkk", "racis*", "slave*", "slavery", "genocide", "ethnic",
+		"gypsies", "midget*", "cripple*",
+	}},
+	{name: "drugs", rating: RatingTeen, words: []string{
+		"drug*", "cocaine", "crack", "heroin", "meth", "weed", "marijuana", "booze", "drunk*",
+		"alcohol*", "beer*", "vodka", "whiskey", "lsd", "pills", "opium", "cigarette*", "bong",
+		"bongs", "roofie*", "stoned", "hangover", "keg", "kegs", "kegger*", "tequila",
+	}},
+	{name: "violence", rating: RatingTeen, words: []string{
+		"kill*", "murder*", "dead", "death*", "die", "dies", "dying", "gun", "guns", "gunned",
+		"gunfire", "gunshot*", "gunpoint", "shoot*", "stab", "stabs", "stabbed", "stabbing*",
+		"blood*", "war", "wars", "bomb", "bombs", "bombed", "bombing*", "bomber*", "terroris*",
+		"torture*", "suicide*", "corpse*", "decapitat*", "execution*", "massacre*", "abortion*",
+		"school shooting*", "dismember*",
+	}},
+	{name: "profanity", rating: RatingTeen, words: []string{
+		"fuck*", "shit*", "damn*", "bitch*", "ass", "asses", "assless", "asshole*", "bastard*",
+		"crap", "crappy", "crapped", "crapping", "piss*", "cunt*", "goddamn*", "douche*", "whore*",
+		"slut*",
+	}},
+	{name: "gross", rating: RatingTeen, words: []string{
+		"poop*", "fart", "farts", "farted", "farting", "vomit*", "puke*", "diarrhea", "pee",
+		"peeing", "urine", "feces", "turd*", "booger*", "snot", "menstrua*", "tampon*", "pus",
+		"scat",
+	}},
+	{name: "religion", rating: RatingTeen, words: []string{
+		"jesus", "god", "god's", "pope", "church*", "bible*", "allah", "satan*", "priest*",
+		"christ", "muhammad", "jew", "jews", "jewish", "muslim*", "christian*", "mormon*",
+		"catholic*",
+	}},
+}
+
+const (
+	// one card key per line followed by its categories, "a:Base:123 sex drugs". these replace the
+	// categories the word lists come up with, a key on its own marks the card family friendly.
+	cardTagsFile = "card-tags.txt"
+
+	// cards the channel's moderators blocked with !blockcard
+	blockedCardsFile = "blocked-cards.json"
+)
+
+// tagCard is the content categories a card's text falls in
+func tagCard(text string) []string {
+	lower := strings.ToLower(text)
+	words := strings.FieldsFunc(lower, func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+
+	var tags []string
+	for _, category := range contentCategories {
+		if categoryMatches(category, lower, words) {
+			tags = append(tags, category.name)
+		}
+	}
+	return tags
+}
+
+func categoryMatches(category contentCategory, lower string, words []string) bool {
+	for _, w := range category.words {
+		if strings.Contains(w, " ") {
+			// phrases are matched against the whole text
+			if strings.Contains(lower, strings.TrimSuffix(w, "*")) {
+				return true
+			}
+			continue
+		}
+		for _, word := range words {
+			word = strings.TrimSuffix(word, "'s")
+			if strings.HasSuffix(w, "*") && strings.HasPrefix(word, strings.TrimSuffix(w, "*")) || word == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// tagsRating is the rating a card needs for the given categories
+func tagsRating(tags []string) contentRating {
+	rating := RatingFamily
+	for _, tag := range tags {
+		for _, category := range contentCategories {
+			if category.name == tag && category.rating > rating {
+				rating = category.rating
+			}
+		}
+	}
+	return rating
+}
+
+// filterRating keeps the cards rated at or below max. tagLines are card-tags.txt's corrections.
+func (b *cardBox) filterRating(max contentRating, tagLines []string) (*cardBox, error) {
+	if max == RatingMature {
+		return b, nil
+	}
+
+	tags := make(map[string][]string)
+	for _, line := range tagLines {
+		fields := strings.Fields(line)
+		tags[fields[0]] = fields[1:]
+	}
+
+	rating := func(kind string, c card) contentRating {
+		if t, ok := tags[cardKey(kind, c)]; ok {
+			return tagsRating(t)
+		}
+		return tagsRating(tagCard(c.Text))
+	}
+
+	filtered := &cardBox{}
+	for _, q := range b.questions {
+		if rating(questionKind, q.card) <= max {
+			filtered.questions = append(filtered.questions, q)
+		}
+	}
+	for _, a := range b.answers {
+		if rating(answerKind, a.card) <= max {
+			filtered.answers = append(filtered.answers, a)
+		}
+	}
+
+	if len(filtered.questions) == 0 || len(filtered.answers) == 0 {
+		return nil, fmt.Errorf("there aren't enough %s cards in these decks", max)
+	}
+
+	return filtered, nil
+}
+
+type blockedCard struct {
+	Key  string    `json:"key"`
+	Text string    `json:"text"`
+	By   string    `json:"by"`
+	Date time.Time `json:"date"`
+}
+
+// loadBlockedCards is the keys of the cards blocked in the channel
+func loadBlockedCards(store *dataStore, channel string) ([]string, error) {
+	var blocked []blockedCard
+	if err := store.load(channelFile(channel, blockedCardsFile), &blocked); err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for _, c := range blocked {
+		keys = append(keys, c.Key)
+	}
+	return keys, nil
+}
+
+// cardsToBlock finds the cards "!blockcard 2" or "!blockcard q" means: the answers shown as [2]
+// this round, or the last round's once the next one has started, or the round's question
+func (g *game) cardsToBlock(arg string) ([]blockedCard, error) {
+	round, err := g.getCurrentRound()
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.EqualFold(arg, "q") {
+		if round.question.ID < 0 {
+			return nil, errors.New("the haiku card stays")
+		}
+		return []blockedCard{{Key: cardKey(questionKind, round.question.card), Text: round.question.Text}}, nil
+	}
+
+	if round.state == RoundPlaying {
+		// with no undo window, or God Is Dead, the next round starts as soon as the answers are judged
+		g.roundsMtx.RLock()
+		if len(g.rounds) > 1 {
+			round = &g.rounds[len(g.rounds)-2]
+		}
+		g.roundsMtx.RUnlock()
+	}
+	if round.state != RoundCzar && round.state != RoundOver {
+		return nil, errors.New("wait until the answers are shown, or !blockcard q for the question")
+	}
+
+	var i int
+	if _, err = fmt.Sscan(arg, &i); err != nil || i < 0 || i >= len(round.cards) {
+		return nil, fmt.Errorf("pick an answer from 0-%d, or q for the question", len(round.cards)-1)
+	}
+
+	var cards []blockedCard
+	for _, c := range round.cards[i].cards {
+		if c.ID < 0 {
+			continue // blank cards are on the players, not the deck
+		}
+		cards = append(cards, blockedCard{Key: cardKey(answerKind, c.card), Text: c.Text})
+	}
+	if len(cards) == 0 {
+		return nil, errors.New("that's a write-in, there's no card to block")
+	}
+	return cards, nil
+}
+
+// removeBlockedCards takes blocked cards out of the game. cards in someone's hand or on the table
+// go when they're discarded.
+func (g *game) removeBlockedCards(keys []string) {
+	if g.blockedCards == nil {
+		g.blockedCards = make(map[string]bool)
+	}
+	for _, key := range keys {
+		g.blockedCards[key] = true
+	}
+
+	keepAnswers := func(cards []answerCard) []answerCard {
+		var kept []answerCard
+		for _, c := range cards {
+			if g.blockedCards[cardKey(answerKind, c.card)] {
+				g.answerCount--
+				continue
+			}
+			kept = append(kept, c)
+		}
+		return kept
+	}
+	keepQuestions := func(cards []questionCard) []questionCard {
+		var kept []questionCard
+		for _, c := range cards {
+			if g.blockedCards[cardKey(questionKind, c.card)] {
+				g.questionCount--
+				continue
+			}
+			kept = append(kept, c)
+		}
+		return kept
+	}
+
+	g.answerDrawPile = keepAnswers(g.answerDrawPile)
+	g.answerDiscardPile = keepAnswers(g.answerDiscardPile)
+	g.questionDrawPile = keepQuestions(g.questionDrawPile)
+	g.questionDiscardPile = keepQuestions(g.questionDiscardPile)
+}
+
+// blockCard is "!blockcard 2" or "!blockcard q". the cards never come up in the channel again.
+func (b *bot) blockCard(channel, nick string, args []string, game *game) error {
+	if game == nil {
+		b.irc.Say(channel, "No game in progress. !start to start a game")
+		return nil
+	}
+	if len(args) != 1 {
+		b.irc.Say(channel, "Type !blockcard # for an answer this round, or !blockcard q for the question")
+		return nil
+	}
+
+	cards, err := game.cardsToBlock(args[0])
+	if err != nil {
+		b.irc.Say(channel, fmt.Sprintf("%s, %v", nick, err))
+		return nil
+	}
+
+	var blocked []blockedCard
+	err = b.store.update(channelFile(channel, blockedCardsFile), &blocked, func() error {
+		already := make(map[string]bool)
+		for _, c := range blocked {
+			already[c.Key] = true
+		}
+		for _, c := range cards {
+			if already[c.Key] {
+				continue
+			}
+			c.By = nick
+			c.Date = time.Now()
+			blocked = append(blocked, c)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var keys, texts []string
+	for _, c := range cards {
+		keys = append(keys, c.Key)
+		texts = append(texts, fmt.Sprintf("\"%s\"", c.Text))
+	}
+	game.removeBlockedCards(keys)
+	log.Printf("%s blocked %s in %s\n", nick, strings.Join(keys, ", "), channel)
+
+	b.irc.Say(channel, fmt.Sprintf("%s blocked %s. It won't come up here again.", nick, strings.Join(texts, " and ")))
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCardsToBlockAfterNextRoundStarts(t *testing.T) {
+	g := newTestGame(t, "undo=0", []string{"alice", "bob", "carol", "dave"})
+	g.stateMtx.Lock()
+	defer g.stateMtx.Unlock()
+
+	round, _ := g.getCurrentRound()
+	playAll(g, round)
+	var shown []string
+	for _, c := range round.cards[0].cards {
+		if c.ID < 0 {
+			t.Fatal("the first answer shown is a write-in")
+		}
+		shown = append(shown, cardKey(answerKind, c.card))
+	}
+	pickWinner(t, g, round, round.cards[0].nick)
+
+	// with no undo window the next round is already being played
+	next, _ := g.getCurrentRound()
+	if next.number == round.number || next.state != RoundPlaying {
+		t.Fatal("the next round didn't start")
+	}
+
+	cards, err := g.cardsToBlock("0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var blocked []string
+	for _, c := range cards {
+		blocked = append(blocked, c.Key)
+	}
+	if strings.Join(blocked, " ") != strings.Join(shown, " ") {
+		t.Errorf("!blockcard 0 blocks %v, want the last round's %v", blocked, shown)
+	}
+
+	cards, err = g.cardsToBlock("q")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cards) != 1 || cards[0].Key != cardKey(questionKind, next.question.card) {
+		t.Errorf("!blockcard q blocks %v, want this round's question", cards)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// czarRotation is the order players take turns being czar. players who join mid-game are
+// seated right after the current czar so they're up next.
+type czarRotation struct {
+	order     []string
+	current   int // index of the current czar in order, -1 before the first round
+	newcomers int // players seated after the current czar since they took over
+}
+
+func newCzarRotation() czarRotation {
+	return czarRotation{current: -1}
+}
+
+func (c *czarRotation) add(nick string) {
+	i := c.current + 1 + c.newcomers
+	c.order = append(c.order, "")
+	copy(c.order[i+1:], c.order[i:])
+	c.order[i] = nick
+	c.newcomers++
+}
+
+func (c *czarRotation) remove(nick string) {
+	for i, n := range c.order {
+		if n != nick {
+			continue
+		}
+		c.order = append(c.order[:i], c.order[i+1:]...)
+		switch {
+		case i <= c.current:
+			// if it was the czar, the player after them is still next
+			c.current--
+		case i <= c.current+c.newcomers:
+			c.newcomers--
+		}
+		return
+	}
+}
+
+// start picks a random first czar
+func (c *czarRotation) start(skip func(nick string) bool) (string, error) {
+	if len(c.order) == 0 {
+		return "", errors.New("no players left!")
+	}
+	c.current = rand.Intn(len(c.order)) - 1
+	c.newcomers = 0
+	return c.next(skip)
+}
+
+// next moves to the next player in the rotation, passing over anyone skip says can't be czar
+func (c *czarRotation) next(skip func(nick string) bool) (string, error) {
+	c.newcomers = 0
+	for i := 1; i <= len(c.order); i++ {
+		n := (c.current + i) % len(c.order)
+		if n < 0 {
+			n += len(c.order)
+		}
+		if !skip(c.order[n]) {
+			c.current = n
+			return c.order[n], nil
+		}
+	}
+	return "", errors.New("no players left to be czar!")
+}
+
+// upcoming is everyone in the rotation starting with the current czar
+func (c *czarRotation) upcoming() []string {
+	start := c.current
+	if start < 0 {
+		start = 0
+	}
+	var nicks []string
+	for i := 0; i < len(c.order); i++ {
+		nicks = append(nicks, c.order[(start+i)%len(c.order)])
+	}
+	return nicks
+}
+
+// skipCzar is true for players who can't take a turn as czar right now
+func (g *game) skipCzar(nick string) bool {
+	p := g.getPlayer(nick)
+	return p == nil || p.suspended || p.afk
+}
+
+func (g *game) showCzarOrder() {
+	if len(g.czars.order) == 0 {
+		g.sendMsg("Nobody's playing.")
+		return
+	}
+
+	round, _ := g.getCurrentRound()
+
+	var nicks []string
+	for _, nick := range g.czars.upcoming() {
+		switch {
+		case round != nil && round.czar == nick:
+			nick += " (czar)"
+		case g.skipCzar(nick):
+			nick += " (away)"
+		}
+		nicks = append(nicks, nick)
+	}
+	g.sendMsg(fmt.Sprintf("Czar order: %s", strings.Join(nicks, " > ")))
+}
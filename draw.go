@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// the draw strategy decides the order cards get stacked in whenever a pile is shuffled.
+// shuffle is a plain shuffle, fresh puts the cards the channel hasn't seen lately on top,
+// winners deals the cards that win more often sooner.
+type drawStrategy int
+
+const (
+	DrawShuffle drawStrategy = iota
+	DrawFresh
+	DrawWinners
+)
+
+var drawStrategyNames = []string{"shuffle", "fresh", "winners"}
+
+func (d drawStrategy) String() string {
+	return drawStrategyNames[d]
+}
+
+func parseDrawStrategy(name string) (drawStrategy, error) {
+	for i, n := range drawStrategyNames {
+		if strings.EqualFold(name, n) {
+			return drawStrategy(i), nil
+		}
+	}
+	return DrawShuffle, fmt.Errorf("draw needs to be %s, not %q", strings.Join(drawStrategyNames, ", "), name)
+}
+
+const (
+	// the cards dealt in a channel's last few games, for the fresh strategy
+	seenCardsFile = "seen-cards.json"
+	seenGames     = 5
+)
+
+type seenCards struct {
+	Games [][]string `json:"games"` // card keys, oldest game first
+}
+
+// loadDrawHistory loads what the strategy needs to know about the channel's cards
+func (g *game) loadDrawHistory() error {
+	switch g.draw {
+	case DrawFresh:
+		var seen seenCards
+		if err := g.store.load(channelFile(g.channel, seenCardsFile), &seen); err != nil {
+			return err
+		}
+		g.seenCards = make(map[string]bool)
+		for _, keys := range seen.Games {
+			for _, key := range keys {
+				g.seenCards[key] = true
+			}
+		}
+	case DrawWinners:
+		var stats map[string]*cardStats
+		if err := g.store.load(channelFile(g.channel, cardStatsFile), &stats); err != nil {
+			return err
+		}
+		g.cardWeights = make(map[string]float64)
+		for key, s := range stats {
+			if s.Kind == questionKind {
+				// questions that don't fall flat
+				g.cardWeights[key] = float64(s.Dealt-s.Flat+1) / float64(s.Dealt+2)
+			} else {
+				g.cardWeights[key] = float64(s.Won+1) / float64(s.Played+2)
+			}
+		}
+	}
+	return nil
+}
+
+// stackOrder is the order to stack cards in, top of the pile first
+func (g *game) stackOrder(kind string, cards []card) []int {
+	order := rand.Perm(len(cards))
+
+	switch g.draw {
+	case DrawFresh:
+		// unseen cards on top, still shuffled
+		sort.SliceStable(order, func(i, j int) bool {
+			return !g.seenCards[cardKey(kind, cards[order[i]])] && g.seenCards[cardKey(kind, cards[order[j]])]
+		})
+	case DrawWinners:
+		// a weighted shuffle, the bigger a card's weight the closer to the top it tends to be
+		keys := make(map[int]float64)
+		for _, i := range order {
+			weight, ok := g.cardWeights[cardKey(kind, cards[i])]
+			if !ok {
+				weight = 0.5 // never played, give it a fair chance
+			}
+			keys[i] = -math.Log(1-rand.Float64()) / weight
+		}
+		sort.Slice(order, func(i, j int) bool { return keys[order[i]] < keys[order[j]] })
+	}
+
+	return order
+}
+
+func (g *game) stackAnswerCards(cards []answerCard) []answerCard {
+	plain := make([]card, len(cards))
+	for i, c := range cards {
+		plain[i] = c.card
+	}
+
+	var stacked []answerCard
+	for _, i := range g.stackOrder(answerKind, plain) {
+		stacked = append(stacked, cards[i])
+	}
+	return stacked
+}
+
+func (g *game) stackQuestionCards(cards []questionCard) []questionCard {
+	plain := make([]card, len(cards))
+	for i, c := range cards {
+		plain[i] = c.card
+	}
+
+	var stacked []questionCard
+	for _, i := range g.stackOrder(questionKind, plain) {
+		stacked = append(stacked, cards[i])
+	}
+	return stacked
+}
+
+// recordSeenCards remembers the cards dealt this game for the fresh strategy
+func (g *game) recordSeenCards() {
+	if g.store == nil || len(g.cardTally) == 0 {
+		return
+	}
+
+	var keys []string
+	for key, s := range g.cardTally {
+		if s.Dealt > 0 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var seen seenCards
+	err := g.store.update(channelFile(g.channel, seenCardsFile), &seen, func() error {
+		seen.Games = append(seen.Games, keys)
+		if len(seen.Games) > seenGames {
+			seen.Games = seen.Games[len(seen.Games)-seenGames:]
+		}
+		return nil
+	})
+	if err != nil {
+		log.Println(err)
+	}
+}
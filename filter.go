@@ -0,0 +1,228 @@
+package main
+
+import (
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// everything the bot says goes through the outbound filter first, write-ins and card text
+// included. it masks the words in outbound-blocklist.txt, the data dir's and the channel's. long
+// messages go out in parts, and if twitch holds a part back anyway (AutoMod, NOTICE
+// msg_rejected) that part goes out once more with every word the card ratings frown on masked
+// too. the channel's filter-log.json keeps track so
+// the streamer can see what got filtered.
+const (
+	// one word or phrase per line, a trailing * matches anything starting with it
+	outboundBlocklistFile = "outbound-blocklist.txt"
+	filterLogFile         = "filter-log.json"
+	filterLogSize         = 500
+
+	// how long a part hangs around in case twitch rejects it
+	rejectWindow = 5 * time.Second
+	// how long the blocklists are used before they're loaded again, so edits don't need a restart
+	blocklistReload = time.Minute
+	// events are written to the filter log together, off the send path
+	filterLogDelay = 2 * time.Second
+)
+
+type outboundFilter struct {
+	store  *dataStore
+	strict *regexp.Regexp // every word in the content categories, for retries
+
+	mtx      sync.Mutex
+	terms    map[string]blocklist     // by channel
+	recent   map[string][]sentMessage // by channel, newest last
+	pending  map[string][]filterEvent // by channel, not in the filter log yet
+	flushing bool                     // a write of the pending events is coming up
+}
+
+type blocklist struct {
+	re     *regexp.Regexp // nil if there's nothing to mask
+	loaded time.Time
+}
+
+// sentMessage is one part of a message, the way it went out
+type sentMessage struct {
+	original string // before any masking
+	sent     string
+	at       time.Time
+	retried  bool
+}
+
+type filterEvent struct {
+	Date     time.Time `json:"date"`
+	Event    string    `json:"event"` // masked, retried or rejected
+	Original string    `json:"original"`
+	Sent     string    `json:"sent,omitempty"`
+}
+
+func newOutboundFilter(store *dataStore) *outboundFilter {
+	var words []string
+	for _, category := range contentCategories {
+		words = append(words, category.words...)
+	}
+
+	return &outboundFilter{
+		store:   store,
+		strict:  termsRegexp(words),
+		terms:   make(map[string]blocklist),
+		recent:  make(map[string][]sentMessage),
+		pending: make(map[string][]filterEvent),
+	}
+}
+
+// termsRegexp matches any of the terms as whole words, nil if there aren't any
+func termsRegexp(terms []string) *regexp.Regexp {
+	var patterns []string
+	for _, term := range terms {
+		if strings.HasSuffix(term, "*") {
+			patterns = append(patterns, regexp.QuoteMeta(strings.TrimSuffix(term, "*"))+`\w*`)
+		} else {
+			patterns = append(patterns, regexp.QuoteMeta(term))
+		}
+	}
+	if len(patterns) == 0 {
+		return nil
+	}
+	return regexp.MustCompile(`(?i)\b(?:` + strings.Join(patterns, "|") + `)\b`)
+}
+
+// mask keeps the first letter of every match and stars out the rest
+func mask(re *regexp.Regexp, message string) string {
+	if re == nil {
+		return message
+	}
+	return re.ReplaceAllStringFunc(message, func(match string) string {
+		first, size := utf8.DecodeRuneInString(match)
+		return string(first) + strings.Repeat("*", utf8.RuneCountInString(match[size:]))
+	})
+}
+
+// channelTerms is the blocklist for the channel, loaded again once it's blocklistReload old
+func (f *outboundFilter) channelTerms(channel string) *regexp.Regexp {
+	if b, ok := f.terms[channel]; ok && time.Since(b.loaded) < blocklistReload {
+		return b.re
+	}
+
+	var terms []string
+	for _, name := range []string{outboundBlocklistFile, channelFile(channel, outboundBlocklistFile)} {
+		lines, err := f.store.loadLines(name)
+		if err != nil {
+			log.Println(err)
+		}
+		terms = append(terms, lines...)
+	}
+
+	f.terms[channel] = blocklist{re: termsRegexp(terms), loaded: time.Now()}
+	return f.terms[channel].re
+}
+
+// outgoing is the part of a message as it should be said in the channel
+func (f *outboundFilter) outgoing(channel, message string) string {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	sent := mask(f.channelTerms(channel), message)
+	if sent != message {
+		f.log(channel, filterEvent{Event: "masked", Original: message, Sent: sent})
+	}
+
+	now := time.Now()
+	var recent []sentMessage
+	for _, m := range f.recent[channel] {
+		if now.Sub(m.at) < rejectWindow {
+			recent = append(recent, m)
+		}
+	}
+	f.recent[channel] = append(recent, sentMessage{original: message, sent: sent, at: now})
+
+	return sent
+}
+
+// whisper is the message as it should be whispered, twitch doesn't reject whispers so there's
+// nothing to keep track of
+func (f *outboundFilter) whisper(channel, message string) string {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	sent := mask(f.channelTerms(channel), message)
+	if sent != message {
+		f.log(channel, filterEvent{Event: "masked", Original: "(whisper) " + message, Sent: sent})
+	}
+	return sent
+}
+
+// rejected is called when twitch rejects a message in the channel. the notice doesn't say which
+// part, so it's the newest one strict masking would change. it returns that part masked, false
+// if there's nothing worth sending again. a part only gets one retry, if the newest one that
+// could have been rejected is a retry (or already retried) that's it.
+func (f *outboundFilter) rejected(channel string) (string, bool) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	recent := f.recent[channel]
+	for i := len(recent) - 1; i >= 0; i-- {
+		m := &recent[i]
+		if time.Since(m.at) > rejectWindow {
+			continue
+		}
+		if m.retried {
+			break
+		}
+		retry := mask(f.strict, m.sent)
+		if retry == m.sent {
+			continue
+		}
+		m.retried = true
+		// the retry goes out too, so it's kept in case it gets rejected as well
+		f.recent[channel] = append(recent, sentMessage{original: m.original, sent: retry, at: time.Now(), retried: true})
+		f.log(channel, filterEvent{Event: "retried", Original: m.original, Sent: retry})
+		return retry, true
+	}
+
+	var last string
+	if len(recent) > 0 {
+		last = recent[len(recent)-1].original
+	}
+	f.log(channel, filterEvent{Event: "rejected", Original: last})
+	return "", false
+}
+
+// log queues the event for the channel's filter log, f.mtx is held
+func (f *outboundFilter) log(channel string, event filterEvent) {
+	event.Date = time.Now()
+	log.Printf("filter %s in %s: %q -> %q\n", event.Event, channel, event.Original, event.Sent)
+
+	f.pending[channel] = append(f.pending[channel], event)
+	if !f.flushing {
+		f.flushing = true
+		time.AfterFunc(filterLogDelay, f.flush)
+	}
+}
+
+// flush writes the pending events to the filter logs, keeping the newest filterLogSize of them
+func (f *outboundFilter) flush() {
+	f.mtx.Lock()
+	pending := f.pending
+	f.pending = make(map[string][]filterEvent)
+	f.flushing = false
+	f.mtx.Unlock()
+
+	for channel, queued := range pending {
+		var events []filterEvent
+		err := f.store.update(channelFile(channel, filterLogFile), &events, func() error {
+			events = append(events, queued...)
+			if len(events) > filterLogSize {
+				events = events[len(events)-filterLogSize:]
+			}
+			return nil
+		})
+		if err != nil {
+			log.Println(err)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestRejectedRetriesOnePart(t *testing.T) {
+	store, err := newDataStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := newOutboundFilter(store)
+
+	// a long message goes out in parts, only the newest part that strict masking changes goes again
+	parts := splitMessage("Carol wins with a naked butt and that's Round 3, Round 4 starts now", 30, 30)
+	if len(parts) != 3 {
+		t.Fatalf("split into %q", parts)
+	}
+	for _, part := range parts {
+		f.outgoing("#test", part)
+	}
+
+	retry, ok := f.rejected("#test")
+	if !ok {
+		t.Fatal("nothing to retry")
+	}
+	if want := "…b*** and that's Round 3,…"; retry != want {
+		t.Errorf("retrying %q, want %q", retry, want)
+	}
+	if _, ok := f.rejected("#test"); ok {
+		t.Error("the retry got retried")
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Gambling: bet an Awesome Point to play a second answer. if either of your answers wins you
+// keep the point, if not the round's winner takes it.
+
+func (g *game) gamble(nick string, cardIndexes []int) error {
+	if !g.houseRules.has(HouseRuleGamble) {
+		g.sendMsg("Gambling isn't a house rule in this game.")
+		return nil
+	}
+
+	round, err := g.getCurrentRound()
+	if err != nil {
+		return err
+	}
+
+	player, ok := round.players[nick]
+	if !ok {
+		return fmt.Errorf("%q isn't a player in this round", nick)
+	}
+
+	if round.state != RoundPlaying {
+		return errors.New("picking answers for this round is over")
+	}
+
+	if player.awesomePoints < 1 {
+		g.sendMsg(fmt.Sprintf("%s, you need an Awesome Point to gamble.", nick))
+		return nil
+	}
+
+	// the regular answer can come before or after the gamble, whoever plays last has to be able
+	// to gamble too
+	var played []answerCard
+	for _, c := range round.cards {
+		if c.nick != nick {
+			continue
+		}
+		if c.gambled {
+			g.sendMsg(fmt.Sprintf("%s, you already gambled this round.", nick))
+			return nil
+		}
+		played = c.cards
+	}
+
+	if len(cardIndexes) != round.question.NumAnswers {
+		g.sendMsg(fmt.Sprintf("%s, gamble %d cards", nick, round.question.NumAnswers))
+		return nil
+	}
+
+	var answerCards []answerCard
+	for _, cardIndex := range cardIndexes {
+		if cardIndex >= len(player.cards) {
+			g.sendMsg(fmt.Sprintf("%s, pick a number 0-%d", nick, len(player.cards)-1))
+			return nil
+		}
+
+		card := player.cards[cardIndex]
+		if card.blank {
+			g.sendMsg(fmt.Sprintf("%s, you can't gamble a blank card.", nick))
+			return nil
+		}
+
+		for _, cards := range [][]answerCard{played, answerCards} {
+			for _, c := range cards {
+				if c.ID == card.ID {
+					g.sendMsg(fmt.Sprintf("%s, gamble cards you haven't played", nick))
+					return nil
+				}
+			}
+		}
+
+		answerCards = append(answerCards, card)
+	}
+
+	player.awesomePoints--
+	round.cards = append(round.cards, playerAnswerCards{nick: nick, cards: answerCards, gambled: true})
+
+	g.sendMsg(fmt.Sprintf("%s gambled an Awesome Point on a second answer!", nick))
+
+	return nil
+}
+
+// gambledCard is whether nick gambled the card this round
+func gambledCard(round *round, nick string, card answerCard) bool {
+	for _, c := range round.cards {
+		if c.nick != nick || !c.gambled {
+			continue
+		}
+		for _, gambled := range c.cards {
+			if gambled.ID == card.ID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// withdrawGamble takes back the gamble of someone who never played their regular answer and
+// gives them their Awesome Point back
+func (g *game) withdrawGamble(round *round, nick string) {
+	var cards []playerAnswerCards
+	for _, c := range round.cards {
+		if c.nick == nick && c.gambled {
+			if gambler := g.getPlayer(nick); gambler != nil {
+				gambler.awesomePoints++
+			}
+			continue
+		}
+		cards = append(cards, c)
+	}
+	round.cards = cards
+}
+
+// settleGambles gives gamblers their point back if they won, otherwise it goes to the round's winner
+func (g *game) settleGambles(round *round) {
+	for _, c := range round.cards {
+		if !c.gambled {
+			continue
+		}
+
+		var won bool
+		for _, award := range round.awards {
+			if award.nick == c.nick {
+				won = true
+				break
+			}
+		}
+
+		switch {
+		case won:
+			if gambler := g.getPlayer(c.nick); gambler != nil {
+				gambler.awesomePoints++
+			}
+			g.sendMsg(fmt.Sprintf("%s won their bet and keeps their Awesome Point.", c.nick))
+		case len(round.awards) > 0:
+			winner := round.awards[0].nick
+			for _, p := range g.scoreboard() {
+				if p.nick == winner {
+					p.awesomePoints++
+				}
+			}
+			g.sendMsg(fmt.Sprintf("%s lost their bet. %s takes the Awesome Point.", c.nick, winner))
+		default:
+			g.sendMsg(fmt.Sprintf("%s lost their bet.", c.nick))
+		}
+	}
+}
@@ -3,7 +3,6 @@ package main
 import (
 	"errors"
 	"fmt"
-	"log"
 	"math/rand"
 	"sort"
 	"strings"
@@ -13,6 +12,9 @@ import (
 )
 
 type game struct {
+	channel             string
+	store               *dataStore
+	users               *userDirectory
 	players             []*player
 	playersMtx          sync.RWMutex
 	gameStart           time.Time
@@ -20,6 +22,9 @@ type game struct {
 	questionDrawPile    []questionCard
 	answerDiscardPile   []answerCard
 	questionDiscardPile []questionCard
+	answerCount         int // cards in the deck, for checking none go missing
+	questionCount       int
+	blockedCards        map[string]bool // card keys moderators blocked during the game
 	messages            chan string
 	whispers            chan whisper
 	done                chan struct{}
@@ -28,11 +33,36 @@ type game struct {
 	startTimeout        time.Duration
 	roundTimeout        time.Duration
 	czarTimeout         time.Duration
+	confirmWindow       time.Duration // how long a pick can be undone before the next round
 	minPlayers          int
+	maxPlayers          int
+	waitlist            []string
+	spectators          []string
+	kicked              []string
+	kickVotes           map[string][]string   // who voted to kick who
+	cardTally           map[string]*cardStats // what happened to each card this game
+	draw                drawStrategy
+	seenCards           map[string]bool    // cards dealt in the channel's last few games
+	cardWeights         map[string]float64 // how often cards win in the channel
+	handSize            int
 	awesomePointsToWin  int
 	gameStarter         string
+	houseRules          houseRules
+	rando               *player
+	reboot              rebootRules
+	never               neverRules
+	writeIns            writeInRules
+	afk                 afkRules
+	happyEnding         bool // the next round is the haiku round
+	happyEndingPlayed   bool
+	lobby               *lobby
+	czars               czarRotation
 	rounds              []round
 	roundsMtx           sync.RWMutex
+	voting              votingRules
+	scoring             scoringMode
+	// stateMtx serializes chat commands with timers firing on their own goroutines
+	stateMtx sync.Mutex
 }
 
 type player struct {
@@ -40,8 +70,21 @@ type player struct {
 	index         int
 	awesomePoints int
 	cards         []answerCard
+	reboots       int
+	nevers        int
+	peoplesChoice int
+	suspended     bool // left the channel, sitting out until they're back
+	afk           bool // missed too many rounds, sitting out until they play
+	missedRounds  int
 }
 
+// Rando Cardrissian plays a random card from the draw pile every round.
+// if he wins the game, everyone goes home in shame.
+const randoNick = "Rando Cardrissian"
+
+// Happy Ending: the last round of the game is always this one
+var haikuCard = questionCard{card{ID: -1, Text: "Make a haiku.", NumAnswers: 3}}
+
 type whisper struct {
 	nick    string
 	message string
@@ -61,98 +104,140 @@ type round struct {
 	start    time.Time
 	question questionCard
 	cards    []playerAnswerCards
-	players  map[string]player
+	players  map[string]*player
 	czar     string
-	winner   string
+	awards   []roundAward
+	votes    map[string]int
+	// answers the audience liked best, doesn't affect Awesome Points
+	peoplesChoice []string
+	// Survival of the Fittest
+	eliminated  map[int]bool
+	eliminators []string
+	// Never Have I Ever discards per player
+	nevers map[string]int
+	// players who ran out the clock, including the czar
+	missed []string
+	// everyone's Awesome Points before the round was decided, for !undo
+	pointsBefore map[string]int
+	// what the round did to everyone's Awesome Points once it was over, so moderators can
+	// still take it back
+	pointsWon map[string]int
+	undoable  bool
+	judgings  int // times the round has been decided, so old timers know they're stale
+	// over, but the quote, write-ins and card stats wait until it can't be taken back
+	unrecorded bool
 }
 
 type playerAnswerCards struct {
-	nick  string
-	cards []answerCard
+	nick    string
+	cards   []answerCard
+	gambled bool // played with !gamble, on top of their regular answer
 }
 
-func newGame(gameStarter string, awesomePoints int) (*game, error) {
-	if awesomePoints < 1 {
-		// TODO: notify irc
+func newGame(channel, gameStarter string, opts gameOptions, store *dataStore, users *userDirectory) (*game, error) {
+	if opts.awesomePoints < 1 {
 		return nil, errors.New("need to play to at least 1 awesome point")
 	}
 
-	cardBox, err := getCardsFromWeb()
+	cardBox, err := fetchCards()
+	if err != nil {
+		return nil, err
+	}
+
+	if cardBox, err = cardBox.filterDecks(opts.decks); err != nil {
+		return nil, err
+	}
+
+	tags, err := store.loadLines(cardTagsFile)
+	if err != nil {
+		return nil, err
+	}
+	if cardBox, err = cardBox.filterRating(opts.rating, tags); err != nil {
+		return nil, err
+	}
+
+	pruned, err := store.loadLines(channelFile(channel, prunedCardsFile))
+	if err != nil {
+		return nil, err
+	}
+	blocked, err := loadBlockedCards(store, channel)
 	if err != nil {
 		return nil, err
 	}
+	if cardBox, err = cardBox.prune(append(pruned, blocked...)); err != nil {
+		return nil, err
+	}
+
+	writeIns := defaultWriteInRules
+	writeIns.blanks = opts.blanks
+	var blocklist []string
+	for _, name := range []string{writeInBlocklistFile, channelFile(channel, writeInBlocklistFile)} {
+		lines, err := store.loadLines(name)
+		if err != nil {
+			return nil, err
+		}
+		blocklist = append(blocklist, lines...)
+	}
+	writeIns.blocklist = termsRegexp(blocklist)
+
+	answers := cardBox.answers
+	if opts.houseRules.has(HouseRuleWriteIns) {
+		answers = append(answers, newBlankCards(writeIns.blanks)...)
+	}
 
+	rules := opts.houseRules
 	game := game{
+		channel:            channel,
+		store:              store,
+		users:              users,
 		gameStart:          time.Now(),
 		gameStarter:        gameStarter,
-		awesomePointsToWin: awesomePoints,
+		awesomePointsToWin: opts.awesomePoints,
+		houseRules:         rules,
+		reboot:             opts.reboot,
+		never:              opts.never,
+		writeIns:           writeIns,
+		afk:                newAFKRules(opts.afkRounds),
+		voting:             opts.voting,
+		scoring:            newScoringMode(rules),
 		messages:           make(chan string, 10),
 		whispers:           make(chan whisper, 10),
 		done:               make(chan struct{}),
-		minPlayers:         3,
+		czars:              newCzarRotation(),
+		minPlayers:         opts.minPlayers,
+		maxPlayers:         opts.maxPlayers,
+		handSize:           opts.handSize,
 		minStart:           30 * time.Second,
 		startTimeout:       3 * time.Minute,
-		czarTimeout:        2 * time.Minute,
-		roundTimeout:       2 * time.Minute,
-		answerDrawPile:     shuffleAnswerCards(cardBox.answers),
-		questionDrawPile:   shuffleQuestionCards(cardBox.questions),
+		czarTimeout:        opts.czarTimeout,
+		confirmWindow:      opts.confirmWindow,
+		roundTimeout:       opts.roundTimeout,
+		draw:               opts.draw,
+	}
+
+	if err = game.loadDrawHistory(); err != nil {
+		return nil, err
+	}
+	game.answerDrawPile = game.stackAnswerCards(answers)
+	game.questionDrawPile = game.stackQuestionCards(cardBox.questions)
+	game.answerCount = len(answers)
+	game.questionCount = len(cardBox.questions)
+
+	if rules.has(HouseRuleRando) {
+		game.rando = &player{nick: randoNick, index: -1}
 	}
 
 	msg := fmt.Sprintf("New game has started to %d Awesome Points! Type !join to join", game.awesomePointsToWin)
 	game.sendMsg(msg)
+	game.sendMsg(opts.String())
 
-	// start nag timer
-	go func() {
-		ticker := time.NewTicker(60 * time.Second)
-		for {
-			select {
-			case <-ticker.C:
-				if atomic.LoadInt32(&game.started) == 0 {
-					game.playersMtx.RLock()
-					needed := game.minPlayers - len(game.players)
-					game.playersMtx.RUnlock()
-					if needed > 0 {
-						game.sendMsg(fmt.Sprintf("%d more players needed to start! Type !join to join the game", needed))
-					} else {
-						return
-					}
-				}
-				// TODO: select on game.exit (closed chan)
-			}
-		}
-	}()
+	game.openLobby()
 
 	game.join(gameStarter)
 
 	return &game, nil
 }
 
-func shuffleAnswerCards(cards []answerCard) []answerCard {
-	var shuffled []answerCard
-	for _, c := range cards {
-		shuffled = append(shuffled, c)
-	}
-	for i := 0; i < len(shuffled); i++ {
-		x := rand.Intn(len(shuffled))
-		shuffled[i], shuffled[x] = shuffled[x], shuffled[i]
-	}
-
-	return shuffled
-}
-
-func shuffleQuestionCards(cards []questionCard) []questionCard {
-	var shuffled []questionCard
-	for _, c := range cards {
-		shuffled = append(shuffled, c)
-	}
-	for i := 0; i < len(shuffled); i++ {
-		x := rand.Intn(len(shuffled))
-		shuffled[i], shuffled[x] = shuffled[x], shuffled[i]
-	}
-
-	return shuffled
-}
-
 func (g *game) sendMsg(msg string) {
 	g.messages <- msg
 }
@@ -172,6 +257,18 @@ func (g *game) join(nick string) {
 		return
 	}
 
+	if containsNick(g.kicked, nick) {
+		g.sendMsg(fmt.Sprintf("%s, you were kicked out of this game.", nick))
+		return
+	}
+
+	if g.gameIsFull() {
+		g.addToWaitlist(nick)
+		return
+	}
+
+	g.spectators, _ = removeNick(g.spectators, nick)
+
 	if nick == "go_cah" {
 		g.minPlayers = 2 // if the bot's playing I'm probably testing, set min players = 2
 	}
@@ -181,84 +278,149 @@ func (g *game) join(nick string) {
 		nick:          nick,
 		index:         len(g.players),
 		awesomePoints: 0,
-		cards:         g.getNextAnswerCards(10),
+		cards:         g.getNextAnswerCards(g.handSize),
 	}
 
 	g.players = append(g.players, &newPlayer)
+	g.czars.add(nick)
 
-	if atomic.LoadInt32(&g.started) == 0 {
-		needed := g.minPlayers - len(g.players) // TODO: y'know, concurrency. atomic.Add -1
+	if g.lobby != nil {
+		needed := g.minPlayers - len(g.players)
 		if needed > 0 {
 			g.sendMsg(fmt.Sprintf("%s has joined the game! %d more players needed to start!", nick, needed))
-		} else if needed == 0 {
-			// TODO: wait minimum timeout to let people join
-			g.sendMsg(fmt.Sprintf("%s has joined the game! Let's start!", nick))
-			err := g.start()
-			if err != nil {
-				// TODO: abort game?
-				log.Println(err)
-			}
 		} else {
 			g.sendMsg(fmt.Sprintf("%s has joined the game!", nick))
 		}
+		g.lobbyPlayersChanged()
 	} else {
-		// TODO: players entering an existing game should be next up for the czar position
-		g.sendMsg(fmt.Sprintf("%s has joined the game!", nick))
+		g.sendMsg(fmt.Sprintf("%s has joined the game and is up next for czar!", nick))
 	}
 }
 
+func (g *game) getPlayer(nick string) *player {
+	g.playersMtx.RLock()
+	defer g.playersMtx.RUnlock()
+
+	for _, p := range g.players {
+		if p.nick == nick {
+			return p
+		}
+	}
+	return nil
+}
+
 func (g *game) quitPlayer(nick string) error {
 	// TODO
-	// - if they're czar end the round without a winner. give cards back or deal new ones?
-	// - if they're in the round player's list and the round.state is "playing" remove them.
-	// - make sure they don't get added to the next round. remove them from the game players.
 	// - publicly shame them for being scumbags. especially if they're the czar or game starter.
+	return g.dropPlayer(nick, fmt.Sprintf("%s has left the game. scumbag.", nick))
+}
+
+// dropPlayer takes a player out of the game for good, announcing it with msg
+func (g *game) dropPlayer(nick, msg string) error {
+	if !g.removePlayer(nick) {
+		// not playing, but they might have been waiting or watching
+		g.waitlist, _ = removeNick(g.waitlist, nick)
+		g.spectators, _ = removeNick(g.spectators, nick)
+		return nil
+	}
+
+	g.sendMsg(msg)
+	g.czars.remove(nick)
+
+	if g.lobby != nil {
+		delete(g.lobby.ready, nick)
+		g.seatWaitlist()
+		// everyone left might be ready now
+		g.lobbyPlayersChanged()
+		return nil
+	}
+
+	return g.leaveRound(nick)
+}
+
+func (g *game) removePlayer(nick string) bool {
 	g.playersMtx.Lock()
 	defer g.playersMtx.Unlock()
+
 	for i, player := range g.players {
 		if player.nick == nick {
 			g.players = append(g.players[:i], g.players[i+1:]...)
-			g.sendMsg(fmt.Sprintf("%s has left the game. scumbag.", nick))
-			round, err := g.getCurrentRound()
-			if err != nil {
-				return err
-			}
-			if round.czar == nick {
-				round.cards = []playerAnswerCards{} // give players their cards back
-				g.startRound()
-			} else {
-				if round.state == RoundPlaying {
-					delete(round.players, nick)
-					for i, c := range round.cards {
-						if c.nick == nick {
-							round.cards = append(round.cards[:i], round.cards[i+1:]...)
-							break
-						}
-					}
-					g.checkIfRoundOver(round)
-				}
-			}
+			g.discardAnswerCards(player.cards...)
+			return true
+		}
+	}
+	return false
+}
 
-			break
+// leaveRound takes a player who's gone out of the current round
+func (g *game) leaveRound(nick string) error {
+	round, err := g.getCurrentRound()
+	if err != nil {
+		return err
+	}
+
+	if round.state == RoundOver {
+		return nil
+	}
+
+	if round.czar == nick {
+		g.sendMsg(fmt.Sprintf("The czar is gone! Nobody wins Round %d.", round.number))
+		round.state = RoundOver
+		g.discardRandoCards(round)
+		round.cards = []playerAnswerCards{} // give players their cards back
+		return g.startRound()
+	}
+
+	if round.state == RoundPlaying {
+		delete(round.players, nick)
+		var cards []playerAnswerCards
+		for _, c := range round.cards {
+			if c.nick != nick {
+				cards = append(cards, c)
+			}
 		}
+		round.cards = cards
+		g.checkIfRoundOver(round)
 	}
 
 	return nil
 }
 
+// suspendPlayer sits out a player who left the channel. they keep their Awesome Points and
+// cards, and they're back in the next round if they come back.
 func (g *game) suspendPlayer(nick string) error {
-	// TODO: MAYBE just PART'ing will be friendlier than !quit'ing
-	return g.quitPlayer(nick)
+	if g.lobby != nil {
+		return g.quitPlayer(nick)
+	}
+
+	player := g.getPlayer(nick)
+	if player == nil || player.suspended {
+		return nil
+	}
+
+	player.suspended = true
+	g.sendMsg(fmt.Sprintf("%s left the channel. They're sitting out until they come back.", nick))
+
+	return g.leaveRound(nick)
 }
 
 func (g *game) resumePlayer(nick string) error {
-	// TODO
-	// - y'know what, you don't get to keep your awesome points. if you left there's no resume.
+	player := g.getPlayer(nick)
+	if player == nil || !player.suspended {
+		return nil
+	}
+
+	player.suspended = false
+	g.sendMsg(fmt.Sprintf("Welcome back %s! You're in from the next round.", nick))
+
 	return nil
 }
 
 func (g *game) start() error {
 	atomic.StoreInt32(&g.started, 1)
+	if g.houseRules != 0 {
+		g.sendMsg(fmt.Sprintf("House rules: %s", g.houseRules))
+	}
 	err := g.startRound()
 	if err != nil {
 		return err
@@ -267,43 +429,6 @@ func (g *game) start() error {
 	return nil
 }
 
-func (g *game) pickRandomCzar() (string, error) {
-	// TODO: make sure player is still in channel
-	g.playersMtx.RLock()
-	defer g.playersMtx.RUnlock()
-
-	totalPlayers := len(g.players)
-	if totalPlayers == 0 {
-		return "", errors.New("no players left!")
-	}
-	i := rand.Intn(totalPlayers)
-	return g.players[i].nick, nil
-}
-
-func (g *game) pickNextCzar() (string, error) {
-	round, err := g.getCurrentRound()
-	if err != nil {
-		// TODO: log
-		return g.pickRandomCzar()
-	}
-
-	g.playersMtx.RLock()
-	for i, player := range g.players {
-		// TODO: make sure player is still in channel
-		if player.nick == round.czar {
-			if i == len(g.players)-1 {
-				g.playersMtx.RUnlock()
-				return g.players[0].nick, nil
-			}
-			g.playersMtx.RUnlock()
-			return g.players[i+1].nick, nil
-		}
-	}
-	g.playersMtx.RUnlock()
-
-	return g.pickRandomCzar()
-}
-
 func (g *game) getCurrentRound() (*round, error) {
 	g.roundsMtx.RLock()
 	defer g.roundsMtx.RUnlock()
@@ -315,48 +440,104 @@ func (g *game) getCurrentRound() (*round, error) {
 	return &g.rounds[roundIndex], nil
 }
 
-func (g *game) getNextAnswerCard() answerCard {
+// every card is in exactly one place: a draw pile, someone's hand, on the table or a discard
+// pile. drawing takes a card off the draw pile, it only goes on the discard pile once whoever
+// has it is done with it.
+
+// getNextAnswerCard draws an answer card. it's false if every card is in someone's hand.
+func (g *game) getNextAnswerCard() (answerCard, bool) {
+	if len(g.answerDrawPile) == 0 {
+		g.answerDrawPile = g.stackAnswerCards(g.answerDiscardPile)
+		g.answerDiscardPile = nil
+	}
+
 	if len(g.answerDrawPile) == 0 {
-		g.answerDrawPile = shuffleAnswerCards(g.answerDiscardPile)
+		return answerCard{}, false
 	}
 
 	card := g.answerDrawPile[0]
-	g.answerDrawPile = g.answerDrawPile[1:] // TODO: will this cause an empty slice if len == 1?
-	g.answerDiscardPile = append(g.answerDiscardPile, card)
+	g.answerDrawPile = g.answerDrawPile[1:]
+	g.tallyCard(answerKind, card.card).Dealt++
 
-	return card
+	return card, true
 }
 
+// getNextAnswerCards draws up to count answer cards, fewer if the deck runs out
 func (g *game) getNextAnswerCards(count int) []answerCard {
 	var cards []answerCard
 	for i := 0; i < count; i++ {
-		cards = append(cards, g.getNextAnswerCard())
+		card, ok := g.getNextAnswerCard()
+		if !ok {
+			break
+		}
+		cards = append(cards, card)
 	}
 	return cards
 }
 
-func (g *game) getNextQuestionCard() questionCard {
+// discardAnswerCards puts cards on the discard pile, blocked cards leave the game instead
+func (g *game) discardAnswerCards(cards ...answerCard) {
+	for _, c := range cards {
+		if g.blockedCards[cardKey(answerKind, c.card)] {
+			g.answerCount--
+			continue
+		}
+		g.answerDiscardPile = append(g.answerDiscardPile, c)
+	}
+}
+
+func (g *game) discardQuestionCard(c questionCard) {
+	if c.ID == haikuCard.ID {
+		return // not part of the deck
+	}
+	if g.blockedCards[cardKey(questionKind, c.card)] {
+		g.questionCount--
+		return
+	}
+	g.questionDiscardPile = append(g.questionDiscardPile, c)
+}
+
+// getNextQuestionCard draws a question card. it's an error if every question got blocked.
+func (g *game) getNextQuestionCard() (questionCard, error) {
+	if len(g.questionDrawPile) == 0 {
+		g.questionDrawPile = g.stackQuestionCards(g.questionDiscardPile)
+		g.questionDiscardPile = nil
+	}
 	if len(g.questionDrawPile) == 0 {
-		g.questionDrawPile = shuffleQuestionCards(g.questionDiscardPile)
+		return questionCard{}, errors.New("out of question cards")
 	}
 
 	card := g.questionDrawPile[0]
-	g.questionDrawPile = g.questionDrawPile[1:] // TODO: will this cause an empty slice if len == 1?
-	g.questionDiscardPile = append(g.questionDiscardPile, card)
+	g.questionDrawPile = g.questionDrawPile[1:]
+	g.tallyCard(questionKind, card.card).Dealt++
+
+	return card, nil
+}
 
-	return card
+// discardRandoCards puts Rando's answers on the discard pile. he doesn't have a hand to keep them in.
+func (g *game) discardRandoCards(round *round) {
+	for _, c := range round.cards {
+		if c.nick == randoNick {
+			g.discardAnswerCards(c.cards...)
+		}
+	}
 }
 
 func (g *game) startRound() error {
+	g.seatWaitlist()
+
 	g.roundsMtx.RLock()
 	roundNum := len(g.rounds) + 1
 	g.roundsMtx.RUnlock()
 
 	var err error
 	var czar string
+	godIsDead := g.houseRules.has(HouseRuleGodIsDead)
 	if roundNum == 1 {
-		if czar, err = g.pickRandomCzar(); err != nil {
-			return err
+		if !godIsDead {
+			if czar, err = g.czars.start(g.skipCzar); err != nil {
+				return err
+			}
 		}
 	} else {
 		prevRound, err := g.getCurrentRound()
@@ -371,82 +552,126 @@ func (g *game) startRound() error {
 					if cards.nick == p.nick {
 						for i, pcard := range p.cards {
 							if pcard.ID == card.ID {
-								g.answerDiscardPile = append(g.answerDiscardPile, p.cards[i])
-								p.cards[i] = g.getNextAnswerCard()
+								// discard what's in their hand, a played blank card has writing on it
+								g.discardAnswerCards(p.cards[i])
+								if len(p.cards) <= g.handSize {
+									if newCard, ok := g.getNextAnswerCard(); ok {
+										p.cards[i] = newCard
+										break
+									}
+								}
+								// Packing Heat left them with extra cards or the deck ran out, don't replace this one
+								p.cards = append(p.cards[:i], p.cards[i+1:]...)
+								break
 							}
 						}
 					}
 				}
 			}
 		}
+		g.discardRandoCards(prevRound)
 
-		if czar, err = g.pickNextCzar(); err != nil {
-			return err
+		g.discardQuestionCard(prevRound.question)
+
+		if !godIsDead {
+			if czar, err = g.czars.next(g.skipCzar); err != nil {
+				return err
+			}
 		}
 	}
 
+	g.debugCheckDeck(roundNum)
+
 	g.playersMtx.RLock()
 
 	// if the bot is playing it will always be czar.
 	for _, player := range g.players {
-		if player.nick == "go_cah" {
+		if player.nick == "go_cah" && !godIsDead {
 			czar = "go_cah"
 			break
 		}
 	}
 
-	players := make(map[string]player)
+	players := make(map[string]*player)
 	for _, player := range g.players {
-		if player.nick == czar {
+		if player.nick == czar || player.suspended || player.afk {
 			continue
 		}
-		players[player.nick] = *player
+		players[player.nick] = player
 	}
 	g.playersMtx.RUnlock()
 
+	var question questionCard
+	if g.happyEnding {
+		question = haikuCard
+	} else if question, err = g.getNextQuestionCard(); err != nil {
+		g.sendMsg("That was the last question card. Game over!")
+		g.gameOver()
+		return nil
+	}
+
 	r := round{
 		number:   roundNum,
 		start:    time.Now(),
-		question: g.getNextQuestionCard(),
+		question: question,
 		players:  players,
 		czar:     czar,
 	}
 
-	// TODO: round ticker, timeout on players, czar
+	if len(g.rounds) > 1 {
+		// once this round starts the one before last can't be taken back
+		g.recordRound(&g.rounds[len(g.rounds)-2])
+	}
 
 	g.roundsMtx.Lock()
 	g.rounds = append(g.rounds, r)
 	g.roundsMtx.Unlock()
 
-	g.sendMsg(fmt.Sprintf("Round %d! %s is the card czar", r.number, r.czar))
+	if godIsDead {
+		g.sendMsg(fmt.Sprintf("Round %d! God is dead, everybody plays and everybody votes", r.number))
+	} else {
+		g.sendMsg(fmt.Sprintf("Round %d! %s is the card czar", r.number, r.czar))
+	}
 	g.sendMsg(fmt.Sprintf("QUESTION: %s", r.question.Text))
 
-	var msgTemplate string
-	if r.question.NumAnswers == 1 {
-		msgTemplate = "Your cards are: %s | Type !play # to play"
-	} else {
-		msgTemplate = "Your cards are: %s | Type !play # # to play"
+	if g.houseRules.has(HouseRulePackingHeat) && r.question.NumAnswers == 2 {
+		g.sendMsg("Packing Heat! Everyone draws an extra card for this one.")
+		for _, player := range r.players {
+			player.cards = append(player.cards, g.getNextAnswerCards(1)...)
+		}
 	}
 
+	g.startRoundTimer(&g.rounds[len(g.rounds)-1], RoundPlaying, g.roundTimeout)
+
+	msgTemplate := "Your cards are: %s | Type !play" + strings.Repeat(" #", r.question.NumAnswers) + " to play"
+
 	for _, player := range r.players {
-		var playerCards string
-		for i, c := range player.cards {
-			if i != 0 {
-				playerCards += " "
-			}
-			playerCards += fmt.Sprintf("[%d] %s", i, c.Text)
+		msg := fmt.Sprintf(msgTemplate, formatAnswerCards(player.cards))
+		if hasBlankCard(player.cards) {
+			msg += " | Blank card? Whisper me !play # your answer"
 		}
-		g.messagePlayer(player.nick, fmt.Sprintf(msgTemplate, playerCards))
+		g.messagePlayer(player.nick, msg)
 	}
 
 	return nil
 }
 
+func formatAnswerCards(cards []answerCard) string {
+	var playerCards string
+	for i, c := range cards {
+		if i != 0 {
+			playerCards += " "
+		}
+		playerCards += fmt.Sprintf("[%d] %s", i, c.Text)
+	}
+	return playerCards
+}
+
 func (g *game) messagePlayer(nick, message string) {
 	g.whispers <- whisper{nick: nick, message: message}
 }
 
-func (g *game) play(nick string, cardIndexes []int) error {
+func (g *game) play(nick string, cardIndexes []int, writeIn string) error {
 	round, err := g.getCurrentRound()
 	if err != nil {
 		return err
@@ -454,6 +679,9 @@ func (g *game) play(nick string, cardIndexes []int) error {
 
 	player, ok := round.players[nick]
 	if !ok {
+		if p := g.getPlayer(nick); p != nil && p.afk {
+			return g.back(nick)
+		}
 		return fmt.Errorf("%q isn't a player in this round", nick)
 	}
 
@@ -488,15 +716,42 @@ func (g *game) play(nick string, cardIndexes []int) error {
 			}
 		}
 
+		if gambledCard(round, nick, answerCard) {
+			g.sendMsg(fmt.Sprintf("%s, you gambled that card. Play a different one.", nick))
+			return nil
+		}
+
+		if answerCard.blank {
+			if writeIn == "" {
+				g.messagePlayer(nick, "That's a blank card. Whisper me !play # your answer")
+				return nil
+			}
+			if reason := g.checkWriteIn(writeIn); reason != "" {
+				g.messagePlayer(nick, fmt.Sprintf("Can't write that on a card: %s.", reason))
+				return nil
+			}
+			answerCard.Text = writeIn
+			writeIn = ""
+		}
+
 		answerCards = append(answerCards, answerCard)
 	}
 
+	if writeIn != "" {
+		if hasBlankCard(answerCards) {
+			g.messagePlayer(nick, "You can only fill in one blank card at a time.")
+		} else {
+			g.messagePlayer(nick, "You can only write on a blank card.")
+		}
+		return nil
+	}
+
 	pcards := playerAnswerCards{nick: nick, cards: answerCards}
 
 	// allow player to change their mind on the card they played
 	var found bool
 	for i, c := range round.cards {
-		if c.nick == nick {
+		if c.nick == nick && !c.gambled {
 			round.cards[i] = pcards
 			found = true
 			g.messagePlayer(nick, fmt.Sprintf("Your answer for Round %d has been changed!", round.number))
@@ -515,28 +770,81 @@ func (g *game) play(nick string, cardIndexes []int) error {
 }
 
 func (g *game) checkIfRoundOver(round *round) {
-	if len(round.cards) == len(round.players) {
+	if g.countAnswers(round) == len(round.players) {
 		// round over! show the answers
 		round.state = RoundCzar
-		g.sendMsg(fmt.Sprintf("Round %d! Here are the answers:", round.number))
+
+		// Rando doesn't have a hand, he plays straight off the top of the draw pile
+		if g.rando != nil {
+			randoCards := playerAnswerCards{nick: g.rando.nick}
+			for len(randoCards.cards) < round.question.NumAnswers {
+				card, ok := g.getNextAnswerCard()
+				if !ok {
+					break
+				}
+				if card.blank { // Rando can't write
+					g.discardAnswerCards(card)
+					continue
+				}
+				randoCards.cards = append(randoCards.cards, card)
+			}
+			if len(randoCards.cards) == round.question.NumAnswers {
+				round.cards = append(round.cards, randoCards)
+			} else {
+				g.discardAnswerCards(randoCards.cards...)
+			}
+		}
 
 		round.cards = g.randomize(round.cards)
+		g.showAnswers(round)
 
-		for i, v := range round.cards {
-			msg := fmt.Sprintf("[%d] %s", i, round.question.Text)
-			for _, c := range v.cards {
-				if strings.Contains(msg, "_") {
-					msg = strings.Replace(msg, "_", c.Text, 1)
-				} else {
-					msg += " " + c.Text
-				}
+		if g.houseRules.has(HouseRuleGodIsDead) {
+			g.startVoting(round)
+		} else if awards := g.scoring.start(g, round); awards != nil {
+			// nothing to judge
+			g.endRound(round, awards)
+		} else {
+			g.startRoundTimer(round, RoundCzar, g.czarTimeout)
+			if g.voting.viewers {
+				round.votes = make(map[string]int)
+				g.sendMsg("Not playing? Vote for People's Choice by typing !vote #")
 			}
-			g.sendMsg(msg)
 		}
-		g.sendMsg(fmt.Sprintf("%s, pick the winner by typing !winner #", round.czar))
 	}
 }
 
+func (g *game) showAnswers(round *round) {
+	g.sendMsg(fmt.Sprintf("Round %d! Here are the answers:", round.number))
+
+	for i, v := range round.cards {
+		g.sendMsg(fmt.Sprintf("[%d] %s", i, fillInAnswer(round.question, v.cards)))
+	}
+}
+
+// fillInAnswer puts the answers in the question's blanks, or after the question if it doesn't have any
+func fillInAnswer(question questionCard, answers []answerCard) string {
+	msg := question.Text
+	for _, c := range answers {
+		if strings.Contains(msg, "_") {
+			msg = strings.Replace(msg, "_", c.Text, 1)
+		} else {
+			msg += " " + c.Text
+		}
+	}
+	return msg
+}
+
+// countAnswers is how many players have played, gambles don't count
+func (g *game) countAnswers(round *round) int {
+	var count int
+	for _, c := range round.cards {
+		if !c.gambled {
+			count++
+		}
+	}
+	return count
+}
+
 func (g *game) randomize(cards []playerAnswerCards) []playerAnswerCards {
 	var shuffled []playerAnswerCards
 	for _, c := range cards {
@@ -550,64 +858,185 @@ func (g *game) randomize(cards []playerAnswerCards) []playerAnswerCards {
 	return shuffled
 }
 
-func (g *game) winner(nick string, cardIndex int) error {
+func (g *game) winner(nick string, picks []int) error {
 	round, err := g.getCurrentRound()
 	if err != nil {
 		return err
 	}
 
-	if round.czar != nick {
-		return fmt.Errorf("%q is not the card czar for this round", nick)
-	}
-
-	if round.state != RoundCzar {
+	if round.state != RoundCzar || g.houseRules.has(HouseRuleGodIsDead) {
 		return errors.New("it's not the czar's turn to pick a winner")
 	}
 
-	if cardIndex >= len(round.cards) {
-		g.sendMsg(fmt.Sprintf("%s, pick a number 0-%d", nick, len(round.cards)-1))
-		return nil
+	awards, err := g.scoring.judge(g, round, nick, picks)
+	if err != nil || awards == nil {
+		return err
 	}
 
-	var gameOver bool
+	return g.endRound(round, awards)
+}
 
-	round.winner = round.cards[cardIndex].nick
+// endRound hands out the round's Awesome Points, then either ends the game or starts the next round
+func (g *game) endRound(round *round, awards []roundAward) error {
+	round.awards = awards
 	round.state = RoundOver
 
-	var winnerAwesomePoints int
-	for _, player := range g.players {
-		if player.nick == round.winner {
-			player.awesomePoints++
-			winnerAwesomePoints = player.awesomePoints
-			if player.awesomePoints >= g.awesomePointsToWin {
-				gameOver = true
+	// remember the score in case the pick gets undone
+	round.pointsBefore = make(map[string]int)
+	for _, player := range g.scoreboard() {
+		round.pointsBefore[player.nick] = player.awesomePoints
+	}
+
+	for _, award := range awards {
+		for _, player := range g.scoreboard() {
+			if player.nick == award.nick {
+				player.awesomePoints += award.points
+				if award.points == 1 {
+					g.sendMsg(fmt.Sprintf("%s wins this round and now has a total of %d Awesome Points!", award.nick, player.awesomePoints))
+				} else {
+					g.sendMsg(fmt.Sprintf("%s gets %d Awesome Points and now has a total of %d!", award.nick, award.points, player.awesomePoints))
+				}
 			}
 		}
 	}
 
-	if gameOver {
-		g.sendMsg(fmt.Sprintf("Game Over! %s is the winner with %d Awesome Points!", round.winner, winnerAwesomePoints))
-		awesomest := g.sortByAwesomePoints(g.players)
-		finalStats := "Total Awesome Points: "
-		for i, a := range awesomest {
-			if i != 0 {
-				finalStats += ", "
+	if len(awards) == 0 {
+		g.sendMsg(fmt.Sprintf("Nobody wins Round %d.", round.number))
+	}
+
+	g.settleGambles(round)
+
+	if g.confirmWindow > 0 && len(awards) > 0 && !g.houseRules.has(HouseRuleGodIsDead) {
+		g.openUndoWindow(round)
+		return nil
+	}
+
+	return g.finishRound(round)
+}
+
+// finishRound wraps up a round that's been decided for good
+func (g *game) finishRound(round *round) error {
+	if len(round.awards) > 0 {
+		round.pointsWon = make(map[string]int)
+		for _, player := range g.scoreboard() {
+			if before, ok := round.pointsBefore[player.nick]; ok && player.awesomePoints != before {
+				round.pointsWon[player.nick] = player.awesomePoints - before
 			}
-			finalStats += fmt.Sprintf("%s: %d", a.nick, a.awesomePoints)
 		}
-		g.sendMsg(finalStats)
+	}
+
+	round.unrecorded = true
+
+	if !g.houseRules.has(HouseRuleGodIsDead) {
+		g.awardPeoplesChoice(round)
+	}
+
+	g.updateParticipation(round)
+
+	gameOver := g.reachedAwesomePointsToWin()
+	if g.happyEnding {
+		// the haiku round ends the game, unless it left first place tied. then it's sudden death.
+		g.happyEnding = false
+		g.happyEndingPlayed = true
+	} else if gameOver && g.houseRules.has(HouseRuleHappyEnding) && !g.happyEndingPlayed {
+		g.happyEnding = true
+		g.sendMsg("We have a winner... but first, a Happy Ending. One last round!")
+		gameOver = false
+	}
 
-		// TODO: print overall stats
-		// TODO: stop game
-		close(g.done)
+	if gameOver {
+		g.gameOver()
 		return nil
+	}
+
+	return g.startRound()
+}
+
+// recordRound keeps what a finished round did for good: the card stats, winning write-ins and
+// the Hall of Fame
+func (g *game) recordRound(round *round) {
+	if !round.unrecorded {
+		return
+	}
+	round.unrecorded = false
+
+	g.tallyRound(round)
+	g.saveWinningWriteIns(round)
+	g.saveQuote(round)
+}
+
+// reachedAwesomePointsToWin is true when one player is out in front with enough Awesome Points.
+// more than one point can be handed out a round, if the lead is tied we keep playing.
+func (g *game) reachedAwesomePointsToWin() bool {
+	awesomest := g.sortByAwesomePoints(g.scoreboard())
+	if len(awesomest) == 0 || awesomest[0].awesomePoints < g.awesomePointsToWin {
+		return false
+	}
+
+	if len(awesomest) > 1 && awesomest[1].awesomePoints == awesomest[0].awesomePoints {
+		g.sendMsg(fmt.Sprintf("%s and %s are tied with %d Awesome Points. Sudden death!", awesomest[0].nick, awesomest[1].nick, awesomest[0].awesomePoints))
+		return false
+	}
+
+	return true
+}
+
+func (g *game) gameOver() {
+	awesomest := g.sortByAwesomePoints(g.scoreboard())
+	winner := awesomest[0]
+	if g.rando != nil && winner == g.rando {
+		g.sendMsg(fmt.Sprintf("Game Over! %s wins with %d Awesome Points! Everyone go home in shame.", winner.nick, winner.awesomePoints))
 	} else {
-		g.sendMsg(fmt.Sprintf("%s wins this round and now has a total of %d Awesome Points!", round.winner, winnerAwesomePoints))
+		g.sendMsg(fmt.Sprintf("Game Over! %s is the winner with %d Awesome Points!", winner.nick, winner.awesomePoints))
 	}
 
-	g.startRound()
+	finalStats := "Total Awesome Points: "
+	for i, a := range awesomest {
+		if i != 0 {
+			finalStats += ", "
+		}
+		finalStats += fmt.Sprintf("%s: %d", a.nick, a.awesomePoints)
+	}
+	g.sendMsg(finalStats)
 
-	return nil
+	g.roundsMtx.RLock()
+	for i := range g.rounds {
+		g.recordRound(&g.rounds[i])
+	}
+	g.roundsMtx.RUnlock()
+
+	var peoplesChoice []string
+	for _, a := range awesomest {
+		if a.peoplesChoice > 0 {
+			peoplesChoice = append(peoplesChoice, fmt.Sprintf("%s: %d", a.nick, a.peoplesChoice))
+		}
+	}
+	if len(peoplesChoice) > 0 {
+		g.sendMsg(fmt.Sprintf("People's Choice: %s", strings.Join(peoplesChoice, ", ")))
+	}
+
+	if g.rando != nil && winner == g.rando {
+		g.recordProfiles("")
+	} else {
+		g.recordProfiles(winner.nick)
+	}
+	g.updateRatings(awesomest)
+	g.recordCardStats()
+	g.recordSeenCards()
+	g.sendMsg("!profile for your all-time stats, !leaderboard to see who's the awesomest, !rank for your rating this season.")
+
+	// TODO: stop game
+	close(g.done)
+}
+
+// scoreboard returns everyone who can earn Awesome Points, including Rando
+func (g *game) scoreboard() []*player {
+	players := make([]*player, 0, len(g.players)+1)
+	players = append(players, g.players...)
+	if g.rando != nil {
+		players = append(players, g.rando)
+	}
+	return players
 }
 
 type SortablePlayers struct {
@@ -616,8 +1045,7 @@ type SortablePlayers struct {
 
 func (g *game) sortByAwesomePoints(players []*player) []*player {
 	sortable := SortablePlayers{players: players}
-	sort.Sort(sortable)
-	sort.Reverse(sortable)
+	sort.Stable(sort.Reverse(sortable))
 	return sortable.players
 }
 
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+type houseRules uint
+
+const (
+	HouseRuleRando houseRules = 1 << iota
+	HouseRuleReboot
+	HouseRulePackingHeat
+	HouseRuleHappyEnding
+	HouseRuleGodIsDead
+	HouseRuleSurvival
+	HouseRuleSeriousBusiness
+	HouseRuleNeverHaveIEver
+	HouseRuleWriteIns
+	HouseRuleGamble
+)
+
+// names used in chat to toggle a house rule, and the title we announce it with
+var allHouseRules = []struct {
+	rule  houseRules
+	name  string
+	title string
+}{
+	{rule: HouseRuleRando, name: "rando", title: "Rando Cardrissian"},
+	{rule: HouseRuleReboot, name: "reboot", title: "Rebooting the Universe"},
+	{rule: HouseRulePackingHeat, name: "packingheat", title: "Packing Heat"},
+	{rule: HouseRuleHappyEnding, name: "happyending", title: "Happy Ending"},
+	{rule: HouseRuleGodIsDead, name: "godisdead", title: "God Is Dead"},
+	{rule: HouseRuleSurvival, name: "survival", title: "Survival of the Fittest"},
+	{rule: HouseRuleSeriousBusiness, name: "seriousbusiness", title: "Serious Business"},
+	{rule: HouseRuleNeverHaveIEver, name: "never", title: "Never Have I Ever"},
+	{rule: HouseRuleWriteIns, name: "blanks", title: "Blank Cards"},
+	{rule: HouseRuleGamble, name: "gamble", title: "Gambling"},
+}
+
+func (h houseRules) has(rule houseRules) bool {
+	return h&rule == rule
+}
+
+func (h houseRules) String() string {
+	var titles []string
+	for _, r := range allHouseRules {
+		if h.has(r.rule) {
+			titles = append(titles, r.title)
+		}
+	}
+	if len(titles) == 0 {
+		return "none"
+	}
+	return strings.Join(titles, ", ")
+}
+
+func (h houseRules) names() []string {
+	var names []string
+	for _, r := range allHouseRules {
+		if h.has(r.rule) {
+			names = append(names, r.name)
+		}
+	}
+	return names
+}
+
+func parseHouseRules(names []string) (houseRules, error) {
+	var rules houseRules
+	for _, name := range names {
+		if name == "" || strings.EqualFold(name, "none") {
+			continue
+		}
+		var found bool
+		for _, r := range allHouseRules {
+			if strings.EqualFold(name, r.name) {
+				rules |= r.rule
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("%q isn't a house rule I know", name)
+		}
+	}
+
+	// these all change who judges the round, pick one
+	var judging []string
+	for _, r := range allHouseRules {
+		if rules.has(r.rule) && r.rule&(HouseRuleGodIsDead|HouseRuleSurvival|HouseRuleSeriousBusiness) != 0 {
+			judging = append(judging, r.title)
+		}
+	}
+	if len(judging) > 1 {
+		return 0, fmt.Errorf("can't play %s together", strings.Join(judging, " and "))
+	}
+
+	return rules, nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+// with --debug the deck gets checked between rounds, once the last round's cards are cleared off
+// the table. every card has to be in exactly one place: a draw pile, a discard pile or someone's hand.
+var debugMode bool
+
+// checkDeck finds cards that went missing or ended up in two places at once. only call it between rounds.
+func (g *game) checkDeck() error {
+	answers := make(map[int]string)
+	place := func(c answerCard, where string) error {
+		if other, ok := answers[c.ID]; ok {
+			return fmt.Errorf("answer card %d (%s) is in %s and %s", c.ID, c.Text, other, where)
+		}
+		answers[c.ID] = where
+		return nil
+	}
+
+	for _, c := range g.answerDrawPile {
+		if err := place(c, "the draw pile"); err != nil {
+			return err
+		}
+	}
+	for _, c := range g.answerDiscardPile {
+		if err := place(c, "the discard pile"); err != nil {
+			return err
+		}
+	}
+	for _, p := range g.players {
+		for _, c := range p.cards {
+			if err := place(c, p.nick+"'s hand"); err != nil {
+				return err
+			}
+		}
+	}
+	if len(answers) != g.answerCount {
+		return fmt.Errorf("%d answer cards in play, the deck has %d", len(answers), g.answerCount)
+	}
+
+	questions := make(map[int]bool)
+	var questionCards []questionCard
+	questionCards = append(questionCards, g.questionDrawPile...)
+	questionCards = append(questionCards, g.questionDiscardPile...)
+	for _, q := range questionCards {
+		if questions[q.ID] {
+			return fmt.Errorf("question card %d (%s) is in the deck twice", q.ID, q.Text)
+		}
+		questions[q.ID] = true
+	}
+	if len(questions) != g.questionCount {
+		return fmt.Errorf("%d question cards in play, the deck has %d", len(questions), g.questionCount)
+	}
+
+	return nil
+}
+
+// deckProblem hears about everything checkDeck finds with --debug, tests swap it to fail
+var deckProblem = func(g *game, roundNum int, err error) {
+	log.Printf("%s round %d: %v\n", g.channel, roundNum, err)
+}
+
+func (g *game) debugCheckDeck(roundNum int) {
+	if !debugMode {
+		return
+	}
+	if err := g.checkDeck(); err != nil {
+		deckProblem(g, roundNum, err)
+	}
+}
@@ -0,0 +1,245 @@
+//go:debug randseednop=0
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// go test -run DeckInvariants -seed N plays the same games again
+var seed = flag.Int64("seed", 0, "seed for the random games, 0 for a new one every run")
+
+// a small deck runs out and gets restacked a lot, which is where cards go missing
+func testDeck() (*cardBox, error) {
+	b := &cardBox{}
+	for i := 0; i < 40; i++ {
+		numAnswers := 1
+		if i%4 == 0 {
+			numAnswers = 2
+		}
+		b.questions = append(b.questions, questionCard{card{ID: 1000 + i, Text: fmt.Sprintf("Question %d _?", i), NumAnswers: numAnswers, Expansion: "Base"}})
+	}
+	for i := 0; i < 150; i++ {
+		b.answers = append(b.answers, answerCard{card: card{ID: i, Text: fmt.Sprintf("Answer %d", i), Expansion: "Base"}})
+	}
+	return b, nil
+}
+
+func newTestGame(t *testing.T, options string, nicks []string) *game {
+	fetchCards = testDeck
+	t.Cleanup(func() { fetchCards = getCardsFromWeb })
+
+	store, err := newDataStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts, err := parseGameOptions(strings.Fields(options), defaultGameOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := newGame("#test", nicks[0], opts, store, newUserDirectory())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stop := make(chan struct{})
+	t.Cleanup(func() { close(stop) })
+	go func() {
+		for {
+			select {
+			case <-g.messages:
+			case <-g.whispers:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	g.stateMtx.Lock()
+	defer g.stateMtx.Unlock()
+	for _, nick := range nicks[1:] {
+		g.join(nick)
+	}
+	g.startFromLobby()
+	return g
+}
+
+// playRandomly plays games with the given options, doing whatever players can do at random. the
+// deck gets checked between every round.
+func playRandomly(t *testing.T, options string, rounds int) {
+	var problem error
+	report := deckProblem
+	debugMode = true
+	deckProblem = func(g *game, roundNum int, err error) {
+		if problem == nil {
+			problem = fmt.Errorf("round %d: %v", roundNum, err)
+		}
+	}
+	t.Cleanup(func() {
+		debugMode, deckProblem = false, report
+	})
+
+	seed := *seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	t.Logf("-seed %d", seed)
+	// the game shuffles with the global source, the players use their own
+	rand.Seed(seed)
+	rng := rand.New(rand.NewSource(seed))
+	nextNick := 0
+	newNick := func() string {
+		nextNick++
+		return fmt.Sprintf("player%d", nextNick)
+	}
+
+	played := 0
+	for played < rounds {
+		g := newTestGame(t, options, []string{newNick(), newNick(), newNick(), newNick()})
+
+		stuck := 0
+		for played < rounds && !isOver(g) {
+			g.stateMtx.Lock()
+			number := len(g.rounds)
+			playRandomStep(g, rng, newNick, stuck > 20)
+			if len(g.rounds) != number {
+				played++
+				stuck = 0
+			} else {
+				stuck++
+			}
+			g.stateMtx.Unlock()
+
+			if problem != nil {
+				t.Fatalf("-seed %d %v", seed, problem)
+			}
+		}
+	}
+}
+
+func isOver(g *game) bool {
+	select {
+	case <-g.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// playRandomStep has everyone in the round do something at random. a round that isn't going
+// anywhere times out.
+func playRandomStep(g *game, rng *rand.Rand, newNick func() string, timeUp bool) {
+	round, _ := g.getCurrentRound()
+
+	// whoever quit, got kicked or went AFK gets replaced
+	for len(g.players) < 4 {
+		g.join(newNick())
+	}
+
+	switch round.state {
+	case RoundPlaying:
+		if timeUp {
+			g.playingTimedOut(round)
+			return
+		}
+
+		switch n := rng.Intn(100); {
+		case n < 3:
+			// the clock ran out on whoever hasn't played
+			g.playingTimedOut(round)
+			return
+		case n < 6:
+			g.quitPlayer(g.players[rng.Intn(len(g.players))].nick)
+		case n < 8:
+			g.kick(g.players[rng.Intn(len(g.players))].nick, "testing")
+		case n < 11:
+			blockRandomCards(g, rng)
+		}
+
+		var nicks []string
+		for nick := range round.players {
+			nicks = append(nicks, nick)
+		}
+		sort.Strings(nicks)
+		for _, nick := range nicks {
+			player, ok := round.players[nick]
+			if !ok || round.state != RoundPlaying || len(player.cards) == 0 {
+				return
+			}
+			if rng.Intn(4) == 0 {
+				// sits this one out, for now
+				continue
+			}
+
+			switch rng.Intn(10) {
+			case 0:
+				g.neverHaveIEver(nick, rng.Intn(len(player.cards)))
+			case 1:
+				g.rebootHand(nick)
+			case 2:
+				g.gamble(nick, rng.Perm(len(player.cards))[:round.question.NumAnswers])
+			}
+			if round.state != RoundPlaying || len(player.cards) < round.question.NumAnswers {
+				return
+			}
+
+			picks := rng.Perm(len(player.cards))[:round.question.NumAnswers]
+			var writeIn string
+			for _, i := range picks {
+				if player.cards[i].blank {
+					writeIn = "something else entirely"
+				}
+			}
+			g.play(nick, picks, writeIn)
+		}
+
+	case RoundCzar:
+		if timeUp || rng.Intn(20) == 0 {
+			g.czarTimedOut(round)
+			return
+		}
+
+		judge, picks := round.czar, 1
+		switch s := g.scoring.(type) {
+		case *survivalScoring:
+			judge = s.nextEliminator(g, round)
+		case *seriousBusinessScoring:
+			picks = s.ranks(round)
+		}
+		g.winner(judge, rng.Perm(len(round.cards))[:picks])
+	}
+}
+
+// blockRandomCards blocks a card in someone's hand, one in the draw pile and sometimes the question
+func blockRandomCards(g *game, rng *rand.Rand) {
+	var keys []string
+	if p := g.players[rng.Intn(len(g.players))]; len(p.cards) > 0 {
+		keys = append(keys, cardKey(answerKind, p.cards[rng.Intn(len(p.cards))].card))
+	}
+	if len(g.answerDrawPile) > 0 {
+		keys = append(keys, cardKey(answerKind, g.answerDrawPile[rng.Intn(len(g.answerDrawPile))].card))
+	}
+	if round, err := g.getCurrentRound(); err == nil && round.question.ID >= 0 && rng.Intn(10) == 0 {
+		keys = append(keys, cardKey(questionKind, round.question.card))
+	}
+	g.removeBlockedCards(keys)
+}
+
+func TestDeckInvariants(t *testing.T) {
+	for _, options := range []string{
+		"points=20 undo=0 rules=rando,reboot,never,blanks,gamble,packingheat,happyending blanks=20",
+		"points=20 undo=0 rules=survival,never,blanks,rando nevers=2",
+		"points=20 undo=0 rules=seriousbusiness,reboot,gamble,packingheat reboots=0",
+	} {
+		t.Run(options, func(t *testing.T) {
+			playRandomly(t, options, 1500)
+		})
+	}
+}
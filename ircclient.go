@@ -20,8 +20,10 @@ type ircClient struct {
 	Nick                 string
 	ServerPassword       string
 	PublicMessages       chan ircPRIVMSG
+	Whispers             chan ircWHISPER
 	Joins                chan ircJOIN
 	Parts                chan ircPART
+	Filter               *outboundFilter // nil says everything as is
 
 	// mainConn handles public messages
 	conn         net.Conn
@@ -34,6 +36,9 @@ type ircClient struct {
 
 	exit      chan struct{}
 	connected int32
+	// a long message goes out in parts, nothing else gets said in between
+	sayMtx     sync.Mutex
+	whisperMtx sync.Mutex
 }
 
 type ircUserAction struct {
@@ -42,6 +47,7 @@ type ircUserAction struct {
 	Nick    string
 	User    string
 	Host    string
+	Tags    map[string]string // IRCv3 message tags, like badges and mod
 }
 
 type ircPRIVMSG struct {
@@ -49,6 +55,11 @@ type ircPRIVMSG struct {
 	Message string
 }
 
+type ircWHISPER struct {
+	ircUserAction
+	Message string
+}
+
 type ircJOIN struct {
 	ircUserAction
 }
@@ -92,6 +103,14 @@ func (i *ircClient) Connect() error {
 	if err = i.CAPREQ("twitch.tv", "membership"); err != nil {
 		return err
 	}
+	// needed to receive whispers on the group server
+	if err = i.CAPREQ("twitch.tv", "commands"); err != nil {
+		return err
+	}
+	// tells us who's a moderator
+	if err = i.CAPREQ("twitch.tv", "tags"); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -105,7 +124,9 @@ loop:
 			i.parseLine(line, i.conn)
 		case line := <-i.whisperLineReceiver:
 			log.Printf("(W) > %s\n", line)
+			tags, line := parseTags(line)
 			i.tryParsePING(line, i.whisperConn)
+			i.tryParseWHISPER(line, tags)
 		case <-i.exit:
 			break loop
 		}
@@ -113,10 +134,49 @@ loop:
 }
 
 func (i *ircClient) parseLine(line string, sourceConn net.Conn) {
-	i.tryParsePRIVMSG(line)
+	tags, line := parseTags(line)
+	i.tryParsePRIVMSG(line, tags)
 	i.tryParsePING(line, sourceConn)
 	i.tryParseJOIN(line)
 	i.tryParsePART(line)
+	i.tryParseNOTICE(line, tags)
+}
+
+// parseTags splits the "@key=value;key=value " prefix twitch puts on lines off the rest of the line
+func parseTags(line string) (map[string]string, string) {
+	if !strings.HasPrefix(line, "@") {
+		return nil, line
+	}
+
+	parts := strings.SplitN(line[1:], " ", 2)
+	if len(parts) != 2 {
+		return nil, line
+	}
+
+	tags := make(map[string]string)
+	for _, tag := range strings.Split(parts[0], ";") {
+		kv := strings.SplitN(tag, "=", 2)
+		if len(kv) == 2 {
+			tags[kv[0]] = kv[1]
+		} else {
+			tags[kv[0]] = ""
+		}
+	}
+
+	return tags, parts[1]
+}
+
+// isModerator is true for the channel's mods and the broadcaster
+func (a ircUserAction) isModerator() bool {
+	if a.Tags["mod"] == "1" {
+		return true
+	}
+	for _, badge := range strings.Split(a.Tags["badges"], ",") {
+		if strings.HasPrefix(badge, "broadcaster/") || strings.HasPrefix(badge, "moderator/") {
+			return true
+		}
+	}
+	return strings.EqualFold(a.Nick, strings.TrimPrefix(a.Channel, "#"))
 }
 
 func (i *ircClient) tryParsePING(line string, sourceConn net.Conn) {
@@ -130,7 +190,7 @@ func (i *ircClient) tryParsePING(line string, sourceConn net.Conn) {
 	i.Pong(server, sourceConn)
 }
 
-func (i *ircClient) tryParsePRIVMSG(line string) {
+func (i *ircClient) tryParsePRIVMSG(line string, tags map[string]string) {
 	if i.PublicMessages == nil {
 		return
 	}
@@ -147,6 +207,7 @@ func (i *ircClient) tryParsePRIVMSG(line string) {
 			User:    found[0][2],
 			Host:    found[0][3],
 			Channel: found[0][4],
+			Tags:    tags,
 		},
 		Message: found[0][5],
 	}
@@ -154,6 +215,30 @@ func (i *ircClient) tryParsePRIVMSG(line string) {
 	i.PublicMessages <- ircMsg
 }
 
+func (i *ircClient) tryParseWHISPER(line string, tags map[string]string) {
+	if i.Whispers == nil {
+		return
+	}
+	regex := regexp.MustCompile(`^[:](?P<nick>.+)[!](?P<user>.+)[@](?P<host>.+) WHISPER (?P<target>\S+) [:](?P<msg>.+)`)
+	found := regex.FindAllStringSubmatch(line, -1)
+	if found == nil || len(found) != 1 || len(found[0]) != 6 {
+		return
+	}
+
+	whisper := ircWHISPER{
+		ircUserAction: ircUserAction{
+			Raw:  line,
+			Nick: found[0][1],
+			User: found[0][2],
+			Host: found[0][3],
+			Tags: tags,
+		},
+		Message: found[0][5],
+	}
+
+	i.Whispers <- whisper
+}
+
 func (i *ircClient) tryParseJOIN(line string) {
 	if i.Joins == nil {
 		return
@@ -200,6 +285,28 @@ func (i *ircClient) tryParsePART(line string) {
 	i.Parts <- part
 }
 
+// tryParseNOTICE catches twitch rejecting one of our messages and sends it again, filtered harder
+func (i *ircClient) tryParseNOTICE(line string, tags map[string]string) {
+	if i.Filter == nil || !strings.HasPrefix(tags["msg-id"], "msg_rejected") {
+		return
+	}
+	regex := regexp.MustCompile(`^[:]\S+ NOTICE (?P<channel>[#]\S+) [:](?P<msg>.+)`)
+	found := regex.FindAllStringSubmatch(line, -1)
+	if found == nil || len(found) != 1 || len(found[0]) != 3 {
+		return
+	}
+
+	channel := found[0][1]
+	if part, ok := i.Filter.rejected(channel); ok {
+		// not from the read loop, a slow send would hold up everything else coming in
+		go func() {
+			if err := i.say(channel, part); err != nil {
+				log.Println(err)
+			}
+		}()
+	}
+}
+
 func (i *ircClient) startReader(conn net.Conn, receiver chan string) {
 	go func() {
 		r := bufio.NewReader(conn)
@@ -238,15 +345,50 @@ func (i *ircClient) Join(channel string) error {
 	return i.sendCommand(cmd, i.allServers)
 }
 
+// Say sends the message in parts if it's too long. twitch accepts or rejects each part on its
+// own, so the filter sees them one at a time.
 func (i *ircClient) Say(channel, message string) error {
-	cmd := fmt.Sprintf("PRIVMSG %s :%s", channel, message)
-	return i.sendCommand(cmd, i.mainServer)
+	i.sayMtx.Lock()
+	defer i.sayMtx.Unlock()
+
+	prefix := fmt.Sprintf("PRIVMSG %s :", channel)
+	for _, part := range splitMessage(message, maxLineBytes-len(prefix), maxMessageRunes) {
+		if i.Filter != nil {
+			// masking never makes a part any longer
+			part = i.Filter.outgoing(channel, part)
+		}
+		if err := i.sendCommand(prefix+part, i.mainServer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// say sends a part that's already been split and filtered
+func (i *ircClient) say(channel, part string) error {
+	i.sayMtx.Lock()
+	defer i.sayMtx.Unlock()
+
+	return i.sendCommand(fmt.Sprintf("PRIVMSG %s :%s", channel, part), i.mainServer)
 }
 
 func (i *ircClient) Whisper(channel, nick, message string) error {
-	time.Sleep(750 * time.Millisecond) // TODO: make this async
-	cmd := fmt.Sprintf("PRIVMSG %s :/w %s %s", channel, nick, message)
-	return i.sendCommand(cmd, i.whisperServer)
+	if i.Filter != nil {
+		message = i.Filter.whisper(channel, message)
+	}
+
+	i.whisperMtx.Lock()
+	defer i.whisperMtx.Unlock()
+
+	prefix := fmt.Sprintf("PRIVMSG %s :/w %s ", channel, nick)
+	command := fmt.Sprintf("/w %s ", nick)
+	for _, part := range splitMessage(message, maxLineBytes-len(prefix), maxMessageRunes-len(command)) {
+		time.Sleep(750 * time.Millisecond) // TODO: make this async
+		if err := i.sendCommand(prefix+part, i.whisperServer); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (i *ircClient) Pong(server string, conn net.Conn) error {
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// disruptive people get to sit outside. moderators can !kick anyone straight away, players
+// can vote someone out with a majority. !ban keeps a nick out of every game in the channel.
+
+// a channel's ban list, nick -> ban
+const channelBansFile = "bans.json"
+
+type channelBans struct {
+	Bans map[string]ban `json:"bans"`
+}
+
+type ban struct {
+	By      string    `json:"by"`
+	Expires time.Time `json:"expires"` // zero is forever
+}
+
+const defaultBanDuration = 24 * time.Hour
+
+func (b ban) expired(now time.Time) bool {
+	return !b.Expires.IsZero() && now.After(b.Expires)
+}
+
+// kickTarget cleans up a nick typed in chat, "@Nick" -> "nick"
+func kickTarget(arg string) string {
+	return strings.ToLower(strings.TrimPrefix(arg, "@"))
+}
+
+// kickVotesNeeded is a majority of the players, not counting the one who's getting kicked
+func (g *game) kickVotesNeeded() int {
+	return (len(g.players)-1)/2 + 1
+}
+
+func (g *game) voteKick(nick, target string) error {
+	if g.getPlayer(nick) == nil {
+		return fmt.Errorf("%q isn't playing, they don't get a kick vote", nick)
+	}
+
+	if g.getPlayer(target) == nil {
+		g.sendMsg(fmt.Sprintf("%s, %s isn't playing.", nick, target))
+		return nil
+	}
+
+	if nick == target {
+		g.sendMsg(fmt.Sprintf("%s, just !quit.", nick))
+		return nil
+	}
+
+	if g.kickVotes == nil {
+		g.kickVotes = make(map[string][]string)
+	}
+	if !containsNick(g.kickVotes[target], nick) {
+		g.kickVotes[target] = append(g.kickVotes[target], nick)
+	}
+
+	// only count votes from people who are still playing
+	var votes []string
+	for _, voter := range g.kickVotes[target] {
+		if g.getPlayer(voter) != nil {
+			votes = append(votes, voter)
+		}
+	}
+	g.kickVotes[target] = votes
+
+	needed := g.kickVotesNeeded()
+	if len(votes) < needed {
+		g.sendMsg(fmt.Sprintf("%s voted to kick %s. %d/%d votes. !kick %s to agree.", nick, target, len(votes), needed, target))
+		return nil
+	}
+
+	return g.kick(target, "the players voted")
+}
+
+// kick takes someone out of the game and keeps them from coming back to it
+func (g *game) kick(target, reason string) error {
+	isPlaying := g.getPlayer(target) != nil
+	if !isPlaying && !containsNick(g.waitlist, target) && !containsNick(g.spectators, target) {
+		return fmt.Errorf("%q isn't in the game", target)
+	}
+
+	if !containsNick(g.kicked, target) {
+		g.kicked = append(g.kicked, target)
+	}
+	delete(g.kickVotes, target)
+
+	msg := fmt.Sprintf("%s has been kicked out of the game (%s). Go sit outside.", target, reason)
+	if !isPlaying {
+		g.sendMsg(msg)
+	}
+	return g.dropPlayer(target, msg)
+}
+
+// isBanned is true if nick has a ban in the channel that hasn't expired yet
+func (b *bot) isBanned(channel, nick string) (bool, error) {
+	var bans channelBans
+	if err := b.store.load(channelFile(channel, channelBansFile), &bans); err != nil {
+		return false, err
+	}
+
+	ban, ok := bans.Bans[nick]
+	return ok && !ban.expired(time.Now()), nil
+}
+
+// turnAwayBanned tells a banned nick they can't play and returns true
+func (b *bot) turnAwayBanned(channel, nick string) bool {
+	banned, err := b.isBanned(channel, nick)
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+	if banned {
+		b.irc.Say(channel, fmt.Sprintf("Sorry %s, you're banned from playing here.", nick))
+	}
+	return banned
+}
+
+// banPlayer bans a nick from every game in the channel, "!ban nick 2h" or "!ban nick forever".
+// they're kicked out of a game that's going on.
+func (b *bot) banPlayer(channel, by string, args []string, game *game) error {
+	if len(args) == 0 || len(args) > 2 {
+		b.irc.Say(channel, "Type !ban nick, !ban nick 2h or !ban nick forever")
+		return nil
+	}
+
+	target := kickTarget(args[0])
+	duration := defaultBanDuration
+	if len(args) == 2 {
+		if strings.EqualFold(args[1], "forever") {
+			duration = 0
+		} else {
+			var err error
+			if duration, err = parseDurationOption("ban", args[1], time.Minute, 365*24*time.Hour); err != nil {
+				b.irc.Say(channel, err.Error())
+				return nil
+			}
+		}
+	}
+
+	now := time.Now()
+	newBan := ban{By: by}
+	if duration > 0 {
+		newBan.Expires = now.Add(duration)
+	}
+
+	var bans channelBans
+	err := b.store.update(channelFile(channel, channelBansFile), &bans, func() error {
+		if bans.Bans == nil {
+			bans.Bans = make(map[string]ban)
+		}
+		// nobody needs to see old bans
+		for nick, ban := range bans.Bans {
+			if ban.expired(now) {
+				delete(bans.Bans, nick)
+			}
+		}
+		bans.Bans[target] = newBan
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if duration > 0 {
+		b.irc.Say(channel, fmt.Sprintf("%s is banned from playing for %s.", target, duration))
+	} else {
+		b.irc.Say(channel, fmt.Sprintf("%s is banned from playing. For good.", target))
+	}
+
+	if game != nil {
+		game.kick(target, "banned")
+	}
+
+	return nil
+}
+
+func (b *bot) unbanPlayer(channel string, args []string) error {
+	if len(args) != 1 {
+		b.irc.Say(channel, "Type !unban nick")
+		return nil
+	}
+
+	target := kickTarget(args[0])
+	var bans channelBans
+	var found bool
+	err := b.store.update(channelFile(channel, channelBansFile), &bans, func() error {
+		_, found = bans.Bans[target]
+		delete(bans.Bans, target)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if found {
+		b.irc.Say(channel, fmt.Sprintf("%s is unbanned. Behave.", target))
+	} else {
+		b.irc.Say(channel, fmt.Sprintf("%s isn't banned.", target))
+	}
+	return nil
+}
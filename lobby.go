@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// the lobby is where players wait for a game to start. once there are enough players it waits
+// at least minStart so others can join, unless everyone says they're !ready or the game
+// starter types !start again. if nobody shows up by startTimeout the game is called off.
+type lobby struct {
+	ready        map[string]bool // true is !ready, false is !unready (hold on a minute)
+	countdown    *time.Timer
+	countdownEnd bool
+	timeout      *time.Timer
+	done         chan struct{} // closed when the lobby ends, stops the nag timer
+}
+
+func (g *game) openLobby() {
+	l := &lobby{
+		ready: make(map[string]bool),
+		done:  make(chan struct{}),
+	}
+	g.lobby = l
+
+	l.timeout = time.AfterFunc(g.startTimeout, func() {
+		g.stateMtx.Lock()
+		defer g.stateMtx.Unlock()
+
+		if g.lobby != l {
+			return
+		}
+
+		g.playersMtx.RLock()
+		needed := g.minPlayers - len(g.players)
+		g.playersMtx.RUnlock()
+
+		if needed > 0 {
+			g.closeLobby()
+			g.sendMsg(fmt.Sprintf("Nobody wants to play? Still needed %d more players after %s. Game cancelled.", needed, g.startTimeout))
+			close(g.done)
+			return
+		}
+
+		g.sendMsg("Waited long enough. Let's start!")
+		g.startFromLobby()
+	})
+
+	// start nag timer
+	go func() {
+		ticker := time.NewTicker(60 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if atomic.LoadInt32(&g.started) == 0 {
+					g.playersMtx.RLock()
+					needed := g.minPlayers - len(g.players)
+					g.playersMtx.RUnlock()
+					if needed > 0 {
+						g.sendMsg(fmt.Sprintf("%d more players needed to start! Type !join to join the game", needed))
+					}
+				}
+			case <-l.done:
+				return
+			}
+		}
+	}()
+}
+
+// closeLobby stops the lobby's timers. call it with stateMtx held.
+func (g *game) closeLobby() {
+	if g.lobby == nil {
+		return
+	}
+	if g.lobby.countdown != nil {
+		g.lobby.countdown.Stop()
+	}
+	g.lobby.timeout.Stop()
+	close(g.lobby.done)
+	g.lobby = nil
+}
+
+// lobbyPlayersChanged starts the countdown once there are enough players, and starts the
+// game if nobody needs to wait for it
+func (g *game) lobbyPlayersChanged() {
+	l := g.lobby
+	if l == nil || len(g.players) < g.minPlayers {
+		return
+	}
+
+	if l.countdown == nil {
+		g.sendMsg(fmt.Sprintf("We have enough players! Starting in %s. Type !ready if you're ready, !unready if you need a minute. %s can !start now.", g.minStart, g.gameStarter))
+		l.countdown = time.AfterFunc(g.minStart, func() {
+			g.stateMtx.Lock()
+			defer g.stateMtx.Unlock()
+
+			if g.lobby != l {
+				return
+			}
+			l.countdownEnd = true
+			g.tryStartFromLobby()
+		})
+		return
+	}
+
+	g.tryStartFromLobby()
+}
+
+func (g *game) tryStartFromLobby() {
+	if g.lobby == nil || len(g.players) < g.minPlayers {
+		return
+	}
+
+	allReady := true
+	var waitingOn []string
+	for _, p := range g.players {
+		ready, ok := g.lobby.ready[p.nick]
+		if !ok || !ready {
+			allReady = false
+		}
+		if ok && !ready {
+			waitingOn = append(waitingOn, p.nick)
+		}
+	}
+
+	switch {
+	case allReady:
+		g.sendMsg("Everyone's ready. Let's start!")
+	case g.lobby.countdownEnd && len(waitingOn) == 0:
+		g.sendMsg("Let's start!")
+	case g.lobby.countdownEnd:
+		g.sendMsg(fmt.Sprintf("Waiting on %s. Type !ready when you're ready.", strings.Join(waitingOn, ", ")))
+		return
+	default:
+		return
+	}
+
+	g.startFromLobby()
+}
+
+func (g *game) startFromLobby() {
+	g.closeLobby()
+	if err := g.start(); err != nil {
+		// TODO: abort game?
+		log.Println(err)
+	}
+}
+
+func (g *game) setReady(nick string, ready bool) error {
+	if g.lobby == nil {
+		return fmt.Errorf("%q can't ready up, the game has already started", nick)
+	}
+
+	if g.getPlayer(nick) == nil {
+		g.sendMsg(fmt.Sprintf("%s, !join the game first.", nick))
+		return nil
+	}
+
+	g.lobby.ready[nick] = ready
+	if !ready {
+		g.sendMsg(fmt.Sprintf("%s needs a minute. We'll wait for them to type !ready.", nick))
+		return nil
+	}
+
+	g.tryStartFromLobby()
+	return nil
+}
+
+// forceStart lets the game starter skip the rest of the lobby's wait
+func (g *game) forceStart(nick string) error {
+	if g.lobby == nil {
+		g.sendMsg("Game already in progress. !join to join game")
+		return nil
+	}
+
+	if nick != g.gameStarter {
+		g.sendMsg(fmt.Sprintf("Game is about to start. !join to join game. Only %s can start it early.", g.gameStarter))
+		return nil
+	}
+
+	if needed := g.minPlayers - len(g.players); needed > 0 {
+		g.sendMsg(fmt.Sprintf("%s, %d more players needed to start!", nick, needed))
+		return nil
+	}
+
+	g.sendMsg(fmt.Sprintf("%s is starting the game!", nick))
+	g.startFromLobby()
+	return nil
+}
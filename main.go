@@ -17,6 +17,14 @@ func init() {
 func main() {
 	fmt.Println("go-cah")
 
+	// go-cah stats cards
+	if len(os.Args) > 1 && os.Args[1] == "stats" {
+		if err := runStats(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	//args := os.Args[1:] // for live play
 	args := []string{"--nick=go_cah", "--channel=#judwhite"} // NOTE: for testing
 	err := startBot(args)
@@ -41,5 +49,7 @@ func startBot(args []string) error {
 	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
 	<-signalChan
 
+	// whatever the filter hasn't written to its logs yet
+	bot.irc.Filter.flush()
 	return nil
 }
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Never Have I Ever: discard a card you don't understand, but you have to admit it to everyone.
+
+type neverRules struct {
+	perGame  int // discards allowed per player each game, 0 for no limit
+	perRound int // discards allowed per player each round, 0 for no limit
+}
+
+var defaultNeverRules = neverRules{
+	perRound: 1,
+}
+
+func (r neverRules) String() string {
+	var limits []string
+	if r.perRound > 0 {
+		limits = append(limits, fmt.Sprintf("%d per round", r.perRound))
+	}
+	if r.perGame > 0 {
+		limits = append(limits, fmt.Sprintf("%d per game", r.perGame))
+	}
+	if len(limits) == 0 {
+		return "no limit"
+	}
+	return strings.Join(limits, ", ")
+}
+
+func (g *game) neverHaveIEver(nick string, cardIndex int) error {
+	if !g.houseRules.has(HouseRuleNeverHaveIEver) {
+		g.sendMsg("Never Have I Ever isn't a house rule in this game.")
+		return nil
+	}
+
+	player := g.getPlayer(nick)
+	if player == nil {
+		return fmt.Errorf("%q isn't playing", nick)
+	}
+
+	round, err := g.getCurrentRound()
+	if err != nil {
+		g.sendMsg(fmt.Sprintf("%s, wait for the game to start.", nick))
+		return nil
+	}
+
+	if cardIndex >= len(player.cards) {
+		g.sendMsg(fmt.Sprintf("%s, pick a number 0-%d", nick, len(player.cards)-1))
+		return nil
+	}
+
+	if g.never.perGame > 0 && player.nevers >= g.never.perGame {
+		g.sendMsg(fmt.Sprintf("%s, you've used up your %d discards for this game.", nick, g.never.perGame))
+		return nil
+	}
+
+	if g.never.perRound > 0 && round.nevers[nick] >= g.never.perRound {
+		g.sendMsg(fmt.Sprintf("%s, you've used up your %d discards for this round.", nick, g.never.perRound))
+		return nil
+	}
+
+	card := player.cards[cardIndex]
+	for _, c := range round.cards {
+		if c.nick != nick {
+			continue
+		}
+		for _, played := range c.cards {
+			if played.ID == card.ID {
+				g.sendMsg(fmt.Sprintf("%s, you already played that card. Too late now.", nick))
+				return nil
+			}
+		}
+	}
+
+	if round.nevers == nil {
+		round.nevers = make(map[string]int)
+	}
+	newCard, ok := g.getNextAnswerCard()
+	if !ok {
+		g.sendMsg(fmt.Sprintf("%s, the deck's empty. You're stuck with it.", nick))
+		return nil
+	}
+
+	round.nevers[nick]++
+	player.nevers++
+
+	g.discardAnswerCards(card)
+	g.tallyCard(answerKind, card.card).Mulliganed++
+	player.cards[cardIndex] = newCard
+
+	g.sendMsg(fmt.Sprintf("%s has never heard of \"%s\" and throws it away in shame.", nick, card.Text))
+	g.messagePlayer(nick, fmt.Sprintf("Your new card is [%d] %s", cardIndex, player.cards[cardIndex].Text))
+
+	return nil
+}
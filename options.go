@@ -0,0 +1,287 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// a channel's saved !defaults, applied before the options given to !start
+const channelDefaultsFile = "defaults.json"
+
+type gameOptions struct {
+	awesomePoints int
+	handSize      int
+	minPlayers    int
+	maxPlayers    int
+	roundTimeout  time.Duration
+	czarTimeout   time.Duration
+	voting        votingRules
+	confirmWindow time.Duration
+	blanks        int
+	afkRounds     int
+	reboot        rebootRules
+	never         neverRules
+	draw          drawStrategy
+	rating        contentRating
+	houseRules    houseRules
+	decks         []string // expansions to play with, all of them if empty
+}
+
+var defaultGameOptions = gameOptions{
+	awesomePoints: 5,
+	handSize:      10,
+	minPlayers:    3,
+	maxPlayers:    10,
+	roundTimeout:  2 * time.Minute,
+	czarTimeout:   2 * time.Minute,
+	voting:        defaultVotingRules,
+	confirmWindow: 10 * time.Second,
+	blanks:        defaultWriteInRules.blanks,
+	afkRounds:     defaultAFKRules.after,
+	reboot:        defaultRebootRules,
+	never:         defaultNeverRules,
+	rating:        RatingMature,
+}
+
+// short names for the decks people actually ask for
+var deckAliases = map[string]string{
+	"base": "Base",
+	"1st":  "CAHe1",
+	"2nd":  "CAHe2",
+	"3rd":  "CAHe3",
+	"4th":  "CAHe4",
+	"5th":  "CAHe5",
+	"6th":  "CAHe6",
+	"xmas": "CAHxmas",
+}
+
+type channelDefaults struct {
+	Options []string `json:"options"`
+}
+
+// parseGameOptions applies options like "points=8 hand=12 timeout=90s rules=rando,gamble decks=base,3rd"
+// on top of opts. a bare word is a house rule, so "!start rando" still works.
+func parseGameOptions(args []string, opts gameOptions) (gameOptions, error) {
+	for _, arg := range args {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) == 1 {
+			rules, err := parseHouseRules([]string{arg})
+			if err != nil {
+				return opts, err
+			}
+			opts.houseRules |= rules
+			continue
+		}
+
+		key, value := strings.ToLower(parts[0]), parts[1]
+		var err error
+		switch key {
+		case "points":
+			opts.awesomePoints, err = parseIntOption(key, value, 1, 50)
+		case "hand":
+			opts.handSize, err = parseIntOption(key, value, 5, 20)
+		case "players":
+			opts.minPlayers, err = parseIntOption(key, value, 2, 20)
+		case "max":
+			opts.maxPlayers, err = parseIntOption(key, value, 2, 50)
+		case "afk":
+			opts.afkRounds, err = parseIntOption(key, value, 1, 10)
+		case "blanks":
+			opts.blanks, err = parseIntOption(key, value, 0, 200)
+		case "timeout":
+			opts.roundTimeout, err = parseDurationOption(key, value, 15*time.Second, 10*time.Minute)
+		case "czartimeout":
+			opts.czarTimeout, err = parseDurationOption(key, value, 15*time.Second, 10*time.Minute)
+		case "undo":
+			opts.confirmWindow, err = parseDurationOption(key, value, 0, time.Minute)
+		case "vote":
+			opts.voting.window, err = parseDurationOption(key, value, 10*time.Second, 5*time.Minute)
+		case "viewers":
+			opts.voting.viewers, err = parseOnOffOption(key, value)
+		case "playerweight":
+			opts.voting.playerWeight, err = parseIntOption(key, value, 1, 10)
+		case "viewerweight":
+			opts.voting.viewerWeight, err = parseIntOption(key, value, 1, 10)
+		case "tiebreak":
+			opts.voting.tieBreak, err = parseTieBreak(value)
+		case "rebootcost":
+			opts.reboot.cost, err = parseIntOption(key, value, 0, 10)
+		case "reboots":
+			opts.reboot.maxPerGame, err = parseIntOption(key, value, 0, 20)
+		case "rebootwhen":
+			opts.reboot.windows, err = parseRebootWindows(value)
+		case "nevers":
+			opts.never.perRound, err = parseIntOption(key, value, 0, 10)
+		case "nevergame":
+			opts.never.perGame, err = parseIntOption(key, value, 0, 50)
+		case "draw":
+			opts.draw, err = parseDrawStrategy(value)
+		case "rating":
+			opts.rating, err = parseContentRating(value)
+		case "rules":
+			var rules houseRules
+			if rules, err = parseHouseRules(strings.Split(value, ",")); err == nil {
+				opts.houseRules = rules
+			}
+		case "decks":
+			opts.decks = nil
+			for _, deck := range strings.Split(value, ",") {
+				if deck == "" {
+					return opts, fmt.Errorf("decks: %q has an empty deck name", value)
+				}
+				if expansion, ok := deckAliases[strings.ToLower(deck)]; ok {
+					deck = expansion
+				}
+				opts.decks = append(opts.decks, deck)
+			}
+		default:
+			return opts, fmt.Errorf("%q isn't an option. try points, hand, players, max, timeout, czartimeout, vote, viewers, playerweight, viewerweight, tiebreak, undo, afk, rebootcost, reboots, rebootwhen, nevers, nevergame, draw, rating, blanks, rules or decks", key)
+		}
+		if err != nil {
+			return opts, err
+		}
+	}
+
+	if opts.maxPlayers < opts.minPlayers {
+		return opts, fmt.Errorf("max (%d) can't be less than players (%d)", opts.maxPlayers, opts.minPlayers)
+	}
+
+	// the rules might have been set in pieces, check they still make sense together
+	if _, err := parseHouseRules(opts.houseRules.names()); err != nil {
+		return opts, err
+	}
+
+	return opts, nil
+}
+
+func parseIntOption(key, value string, min, max int) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < min || n > max {
+		return 0, fmt.Errorf("%s needs to be a number from %d to %d, not %q", key, min, max, value)
+	}
+	return n, nil
+}
+
+func parseOnOffOption(key, value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "on", "yes", "true":
+		return true, nil
+	case "off", "no", "false":
+		return false, nil
+	}
+	return false, fmt.Errorf("%s needs to be on or off, not %q", key, value)
+}
+
+func parseDurationOption(key, value string, min, max time.Duration) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		// plain numbers are seconds
+		var n int
+		if n, err = strconv.Atoi(value); err == nil {
+			d = time.Duration(n) * time.Second
+		}
+	}
+	if err != nil || d < min || d > max {
+		return 0, fmt.Errorf("%s needs to be a duration from %s to %s (like 90s), not %q", key, min, max, value)
+	}
+	return d, nil
+}
+
+func (o gameOptions) String() string {
+	decks := "all"
+	if len(o.decks) > 0 {
+		decks = strings.Join(o.decks, ", ")
+	}
+	summary := fmt.Sprintf("Players: %d-%d | Hand: %d cards | Round timeout: %s | Czar timeout: %s | Decks: %s | House rules: %s",
+		o.minPlayers, o.maxPlayers, o.handSize, o.roundTimeout, o.czarTimeout, decks, o.houseRules)
+	if o.houseRules.has(HouseRuleGodIsDead) {
+		summary += fmt.Sprintf(" | Voting: %s", o.voting)
+	} else if !o.voting.viewers {
+		summary += " | People's Choice: off"
+	}
+	if o.houseRules.has(HouseRuleReboot) {
+		summary += fmt.Sprintf(" | Reboot: %s", o.reboot)
+	}
+	if o.houseRules.has(HouseRuleNeverHaveIEver) {
+		summary += fmt.Sprintf(" | Never Have I Ever: %s", o.never)
+	}
+	if o.houseRules.has(HouseRuleWriteIns) {
+		summary += fmt.Sprintf(" | Blank cards: %d", o.blanks)
+	}
+	if o.draw != DrawShuffle {
+		summary += fmt.Sprintf(" | Draw: %s", o.draw)
+	}
+	if o.rating != RatingMature {
+		summary += fmt.Sprintf(" | Rating: %s", o.rating)
+	}
+	return summary
+}
+
+// loadGameOptions starts from the channel's saved defaults and applies args on top
+func (b *bot) loadGameOptions(channel string, args []string) (gameOptions, error) {
+	var defaults channelDefaults
+	if err := b.store.load(channelFile(channel, channelDefaultsFile), &defaults); err != nil {
+		return gameOptions{}, err
+	}
+
+	opts, err := parseGameOptions(defaults.Options, defaultGameOptions)
+	if err != nil {
+		return gameOptions{}, fmt.Errorf("the channel defaults are broken (%v), fix them with !defaults", err)
+	}
+
+	maxRating := opts.rating
+	if opts, err = parseGameOptions(args, opts); err != nil {
+		return gameOptions{}, err
+	}
+
+	// the channel's rating is the most a game can be
+	if opts.rating > maxRating {
+		return gameOptions{}, fmt.Errorf("games here are rated %s at most", maxRating)
+	}
+
+	return opts, nil
+}
+
+// setChannelDefaults saves the options every !start in the channel begins with. only the
+// broadcaster gets to change them.
+func (b *bot) setChannelDefaults(channel, nick string, args []string) error {
+	var defaults channelDefaults
+	if len(args) == 0 {
+		if err := b.store.load(channelFile(channel, channelDefaultsFile), &defaults); err != nil {
+			return err
+		}
+		opts, err := parseGameOptions(defaults.Options, defaultGameOptions)
+		if err != nil {
+			b.irc.Say(channel, fmt.Sprintf("Channel defaults are broken: %v", err))
+			return nil
+		}
+		b.irc.Say(channel, fmt.Sprintf("Channel defaults: %d Awesome Points | %s", opts.awesomePoints, opts))
+		return nil
+	}
+
+	if !strings.EqualFold(nick, strings.TrimPrefix(channel, "#")) {
+		b.irc.Say(channel, fmt.Sprintf("%s, only the broadcaster can change the channel defaults.", nick))
+		return nil
+	}
+
+	// "!defaults reset" goes back to the bot's defaults
+	if len(args) == 1 && strings.EqualFold(args[0], "reset") {
+		args = nil
+	}
+
+	opts, err := parseGameOptions(args, defaultGameOptions)
+	if err != nil {
+		b.irc.Say(channel, err.Error())
+		return nil
+	}
+
+	defaults.Options = args
+	if err = b.store.save(channelFile(channel, channelDefaultsFile), &defaults); err != nil {
+		return err
+	}
+
+	b.irc.Say(channel, fmt.Sprintf("Channel defaults saved: %d Awesome Points | %s", opts.awesomePoints, opts))
+	return nil
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// every finished game goes into the players' all-time profiles. profiles are keyed by twitch
+// user ID so they survive a name change, players we never got an ID for are keyed by nick.
+const profilesFile = "profiles.json"
+
+type profileBook struct {
+	Profiles map[string]*playerProfile `json:"profiles"`
+}
+
+type playerProfile struct {
+	Nick     string                  `json:"nick"` // the nick they last played as
+	Global   playerStats             `json:"global"`
+	Channels map[string]*playerStats `json:"channels"`
+}
+
+type playerStats struct {
+	GamesPlayed int            `json:"games_played"`
+	GamesWon    int            `json:"games_won"`
+	RoundsWon   int            `json:"rounds_won"`
+	CzarTurns   int            `json:"czar_turns"`
+	GamblesWon  int            `json:"gambles_won"`
+	GamblesLost int            `json:"gambles_lost"`
+	Cards       map[string]int `json:"cards"` // winning answers and how often they won
+}
+
+func (s *playerStats) add(other *playerStats) {
+	s.GamesPlayed += other.GamesPlayed
+	s.GamesWon += other.GamesWon
+	s.RoundsWon += other.RoundsWon
+	s.CzarTurns += other.CzarTurns
+	s.GamblesWon += other.GamblesWon
+	s.GamblesLost += other.GamblesLost
+	for text, wins := range other.Cards {
+		if s.Cards == nil {
+			s.Cards = make(map[string]int)
+		}
+		s.Cards[text] += wins
+	}
+}
+
+// favoriteCards is the player's most winning answers, best first
+func (s *playerStats) favoriteCards(n int) []string {
+	var cards []string
+	for text := range s.Cards {
+		cards = append(cards, text)
+	}
+	sort.Slice(cards, func(i, j int) bool {
+		if s.Cards[cards[i]] != s.Cards[cards[j]] {
+			return s.Cards[cards[i]] > s.Cards[cards[j]]
+		}
+		return cards[i] < cards[j]
+	})
+	if len(cards) > n {
+		cards = cards[:n]
+	}
+	return cards
+}
+
+// userDirectory remembers the twitch user ID behind every nick the bot has seen
+type userDirectory struct {
+	mtx sync.Mutex
+	ids map[string]string
+}
+
+func newUserDirectory() *userDirectory {
+	return &userDirectory{ids: make(map[string]string)}
+}
+
+func (d *userDirectory) remember(nick, id string) {
+	if id == "" {
+		return
+	}
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.ids[nick] = id
+}
+
+// profileKey is the key of nick's profile
+func (d *userDirectory) profileKey(nick string) string {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if id, ok := d.ids[nick]; ok {
+		return id
+	}
+	return "nick:" + nick
+}
+
+// gameStats adds up what everyone did this game from the rounds
+func (g *game) gameStats(winner string) map[string]*playerStats {
+	stats := make(map[string]*playerStats)
+	statsFor := func(nick string) *playerStats {
+		if _, ok := stats[nick]; !ok {
+			stats[nick] = &playerStats{GamesPlayed: 1}
+		}
+		return stats[nick]
+	}
+
+	for _, p := range g.players {
+		statsFor(p.nick)
+	}
+
+	for _, round := range g.rounds {
+		for nick := range round.players {
+			statsFor(nick)
+		}
+
+		if round.state != RoundOver {
+			continue
+		}
+
+		if round.czar != "" {
+			statsFor(round.czar).CzarTurns++
+		}
+
+		if len(round.awards) > 0 {
+			top := round.awards[0]
+			s := statsFor(top.nick)
+			s.RoundsWon++
+			if s.Cards == nil {
+				s.Cards = make(map[string]int)
+			}
+			for _, c := range round.cards[top.cardIndex].cards {
+				s.Cards[c.Text]++
+			}
+		}
+
+		for _, c := range round.cards {
+			if !c.gambled {
+				continue
+			}
+			var won bool
+			for _, award := range round.awards {
+				if award.nick == c.nick {
+					won = true
+				}
+			}
+			if won {
+				statsFor(c.nick).GamblesWon++
+			} else {
+				statsFor(c.nick).GamblesLost++
+			}
+		}
+	}
+
+	if winner != "" {
+		statsFor(winner).GamesWon++
+	}
+
+	// Rando doesn't get a profile, he gets enough attention
+	delete(stats, randoNick)
+
+	return stats
+}
+
+// recordProfiles adds the game to everyone's profile
+func (g *game) recordProfiles(winner string) {
+	stats := g.gameStats(winner)
+
+	var book profileBook
+	err := g.store.update(profilesFile, &book, func() error {
+		if book.Profiles == nil {
+			book.Profiles = make(map[string]*playerProfile)
+		}
+		for nick, s := range stats {
+			key := g.users.profileKey(nick)
+			profile, ok := book.Profiles[key]
+			if !ok {
+				profile = &playerProfile{}
+				book.Profiles[key] = profile
+			}
+			if profile.Channels == nil {
+				profile.Channels = make(map[string]*playerStats)
+			}
+			if profile.Channels[g.channel] == nil {
+				profile.Channels[g.channel] = &playerStats{}
+			}
+			profile.Nick = nick
+			profile.Global.add(s)
+			profile.Channels[g.channel].add(s)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// findProfile looks a player up by user ID, then by the nick they last played as
+func (b *bot) findProfile(nick string) (*playerProfile, error) {
+	var book profileBook
+	if err := b.store.load(profilesFile, &book); err != nil {
+		return nil, err
+	}
+
+	if profile, ok := book.Profiles[b.users.profileKey(nick)]; ok {
+		return profile, nil
+	}
+	for _, profile := range book.Profiles {
+		if strings.EqualFold(profile.Nick, nick) {
+			return profile, nil
+		}
+	}
+	return nil, nil
+}
+
+// showProfile says a player's all-time stats, "!profile" for your own or "!profile nick"
+func (b *bot) showProfile(channel, nick string, args []string) error {
+	if len(args) > 0 {
+		nick = kickTarget(args[0])
+	}
+
+	profile, err := b.findProfile(nick)
+	if err != nil {
+		return err
+	}
+	if profile == nil {
+		b.irc.Say(channel, fmt.Sprintf("%s hasn't finished a game yet.", nick))
+		return nil
+	}
+
+	s := profile.Global
+	msg := fmt.Sprintf("%s: %d games, %d wins | %d rounds won | czar %d times | gambles won %d, lost %d",
+		profile.Nick, s.GamesPlayed, s.GamesWon, s.RoundsWon, s.CzarTurns, s.GamblesWon, s.GamblesLost)
+	if cards := s.favoriteCards(3); len(cards) > 0 {
+		msg += fmt.Sprintf(" | favorite cards: %s", strings.Join(cards, ", "))
+	}
+	b.irc.Say(channel, msg)
+	return nil
+}
+
+// showLeaderboard says the top players by games won, for the channel or everywhere
+func (b *bot) showLeaderboard(channel string, args []string) error {
+	global := false
+	if len(args) > 0 {
+		switch strings.ToLower(args[0]) {
+		case "global":
+			global = true
+		case "channel":
+		default:
+			b.irc.Say(channel, "Type !leaderboard, !leaderboard channel or !leaderboard global")
+			return nil
+		}
+	}
+
+	var book profileBook
+	if err := b.store.load(profilesFile, &book); err != nil {
+		return err
+	}
+
+	type entry struct {
+		nick  string
+		stats *playerStats
+	}
+	var entries []entry
+	for _, profile := range book.Profiles {
+		s := &profile.Global
+		if !global {
+			if s = profile.Channels[channel]; s == nil {
+				continue
+			}
+		}
+		entries = append(entries, entry{profile.Nick, s})
+	}
+
+	if len(entries) == 0 {
+		b.irc.Say(channel, "Nobody's finished a game yet.")
+		return nil
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i].stats, entries[j].stats
+		if a.GamesWon != b.GamesWon {
+			return a.GamesWon > b.GamesWon
+		}
+		if a.RoundsWon != b.RoundsWon {
+			return a.RoundsWon > b.RoundsWon
+		}
+		return entries[i].nick < entries[j].nick
+	})
+
+	var top []string
+	for i, e := range entries {
+		if i == 5 {
+			break
+		}
+		top = append(top, fmt.Sprintf("%d. %s %d wins (%d rounds)", i+1, e.nick, e.stats.GamesWon, e.stats.RoundsWon))
+	}
+
+	where := channel
+	if global {
+		where = "everywhere"
+	}
+	b.irc.Say(channel, fmt.Sprintf("Leaderboard (%s): %s", where, strings.Join(top, ", ")))
+	return nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+)
+
+// the Hall of Fame keeps every winning answer. chat can !upvote the latest one, the best of
+// them show up in !fame.
+const quotesFile = "quotes.json"
+
+type quote struct {
+	ID     int       `json:"id"`
+	Text   string    `json:"text"` // the question with the winning answer filled in
+	Author string    `json:"author"`
+	Czar   string    `json:"czar,omitempty"`
+	Round  int       `json:"round"`
+	Date   time.Time `json:"date"`
+	Votes  []string  `json:"votes,omitempty"` // who upvoted it
+}
+
+func (q quote) String() string {
+	return fmt.Sprintf("\"%s\" - %s, %s", q.Text, q.Author, q.Date.Format("Jan 2 2006"))
+}
+
+// saveQuote adds the round's winning answer to the channel's Hall of Fame
+func (g *game) saveQuote(round *round) {
+	if g.store == nil || len(round.awards) == 0 {
+		return
+	}
+
+	award := round.awards[0]
+	newQuote := quote{
+		Text:   fillInAnswer(round.question, round.cards[award.cardIndex].cards),
+		Author: award.nick,
+		Czar:   round.czar,
+		Round:  round.number,
+		Date:   time.Now(),
+	}
+
+	var quotes []quote
+	err := g.store.update(channelFile(g.channel, quotesFile), &quotes, func() error {
+		newQuote.ID = len(quotes) + 1
+		quotes = append(quotes, newQuote)
+		return nil
+	})
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// showQuote says a random quote from the Hall of Fame, "!quote nick" for one of theirs
+func (b *bot) showQuote(channel string, args []string) error {
+	var quotes []quote
+	if err := b.store.load(channelFile(channel, quotesFile), &quotes); err != nil {
+		return err
+	}
+
+	if len(args) > 0 {
+		nick := kickTarget(args[0])
+		var theirs []quote
+		for _, q := range quotes {
+			if strings.EqualFold(q.Author, nick) {
+				theirs = append(theirs, q)
+			}
+		}
+		if len(theirs) == 0 {
+			b.irc.Say(channel, fmt.Sprintf("%s hasn't won a round here yet.", nick))
+			return nil
+		}
+		quotes = theirs
+	}
+
+	if len(quotes) == 0 {
+		b.irc.Say(channel, "The Hall of Fame is empty. Go win a round.")
+		return nil
+	}
+
+	q := quotes[rand.Intn(len(quotes))]
+	b.irc.Say(channel, fmt.Sprintf("#%d %s", q.ID, q))
+	return nil
+}
+
+// showFame says the most upvoted quotes
+func (b *bot) showFame(channel string) error {
+	var quotes []quote
+	if err := b.store.load(channelFile(channel, quotesFile), &quotes); err != nil {
+		return err
+	}
+
+	var fame []quote
+	for _, q := range quotes {
+		if len(q.Votes) > 0 {
+			fame = append(fame, q)
+		}
+	}
+
+	if len(fame) == 0 {
+		b.irc.Say(channel, "Nobody's in the Hall of Fame yet. !upvote the next winner you like.")
+		return nil
+	}
+
+	sort.SliceStable(fame, func(i, j int) bool {
+		return len(fame[i].Votes) > len(fame[j].Votes)
+	})
+
+	for i, q := range fame {
+		if i == 3 {
+			break
+		}
+		b.irc.Say(channel, fmt.Sprintf("%d. %s (%d votes)", i+1, q, len(q.Votes)))
+	}
+	return nil
+}
+
+// upvoteQuote gives the latest winning answer a vote. one vote each, and not for your own.
+func (b *bot) upvoteQuote(channel, nick string) error {
+	var quotes []quote
+	var said string
+	err := b.store.update(channelFile(channel, quotesFile), &quotes, func() error {
+		if len(quotes) == 0 {
+			said = "There's nothing to upvote yet."
+			return nil
+		}
+
+		q := &quotes[len(quotes)-1]
+		switch {
+		case q.Author == nick:
+			said = fmt.Sprintf("%s, nice try.", nick)
+		case containsNick(q.Votes, nick):
+			said = fmt.Sprintf("%s, you already upvoted that one.", nick)
+		default:
+			q.Votes = append(q.Votes, nick)
+			said = fmt.Sprintf("%s upvoted #%d. %d votes.", nick, q.ID, len(q.Votes))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	b.irc.Say(channel, said)
+	return nil
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// every finished game updates the players' Elo ratings. each player is treated as having played
+// everyone else, beating the players who finished below them. ratings are per channel and reset
+// every season, a season is a calendar quarter.
+const (
+	ratingsFile   = "ratings.json"
+	seasonsDir    = "seasons"
+	initialRating = 1500
+	ratingK       = 32
+)
+
+type channelRatings struct {
+	Season  string                 `json:"season"`
+	Ratings map[string]*ratingInfo `json:"ratings"` // by profile key
+}
+
+type ratingInfo struct {
+	Nick   string  `json:"nick"`
+	Rating float64 `json:"rating"`
+	Games  int     `json:"games"`
+}
+
+// a season's final standings, saved when the next season starts
+type seasonStandings struct {
+	Season    string        `json:"season"`
+	Standings []*ratingInfo `json:"standings"`
+}
+
+// seasonOf is the season t falls in, like "2024-Q3"
+func seasonOf(t time.Time) string {
+	return fmt.Sprintf("%d-Q%d", t.Year(), (int(t.Month())-1)/3+1)
+}
+
+// seasonEnd is when the season t falls in ends
+func seasonEnd(t time.Time) time.Time {
+	firstMonth := time.Month((int(t.Month())-1)/3*3 + 1)
+	return time.Date(t.Year(), firstMonth+3, 1, 0, 0, 0, 0, t.Location())
+}
+
+func (r *channelRatings) standings() []*ratingInfo {
+	var standings []*ratingInfo
+	for _, info := range r.Ratings {
+		standings = append(standings, info)
+	}
+	sort.Slice(standings, func(i, j int) bool {
+		if standings[i].Rating != standings[j].Rating {
+			return standings[i].Rating > standings[j].Rating
+		}
+		return standings[i].Nick < standings[j].Nick
+	})
+	return standings
+}
+
+// startSeason archives the old season's standings and resets the ratings if the season is over.
+// it runs inside store.update, so the store's already locked.
+func startSeason(store *dataStore, channel string, ratings *channelRatings, now time.Time) error {
+	season := seasonOf(now)
+	if ratings.Season == season {
+		return nil
+	}
+
+	if ratings.Season != "" && len(ratings.Ratings) > 0 {
+		archive := seasonStandings{Season: ratings.Season, Standings: ratings.standings()}
+		if err := store.write(channelFile(channel, filepath.Join(seasonsDir, ratings.Season+".json")), &archive); err != nil {
+			return err
+		}
+	}
+
+	ratings.Season = season
+	ratings.Ratings = make(map[string]*ratingInfo)
+	return nil
+}
+
+// updateRatings rates the game from the final standings, best first
+func (g *game) updateRatings(standings []*player) {
+	var players []*player
+	for _, p := range standings {
+		if p != g.rando {
+			players = append(players, p)
+		}
+	}
+	if len(players) < 2 {
+		return
+	}
+
+	var ratings channelRatings
+	err := g.store.update(channelFile(g.channel, ratingsFile), &ratings, func() error {
+		if err := startSeason(g.store, g.channel, &ratings, time.Now()); err != nil {
+			return err
+		}
+
+		infos := make([]*ratingInfo, len(players))
+		for i, p := range players {
+			key := g.users.profileKey(p.nick)
+			if ratings.Ratings[key] == nil {
+				ratings.Ratings[key] = &ratingInfo{Rating: initialRating}
+			}
+			infos[i] = ratings.Ratings[key]
+			infos[i].Nick = p.nick
+		}
+
+		// work out every change from the ratings before the game
+		changes := make([]float64, len(players))
+		for i := range players {
+			for j := range players {
+				if i == j {
+					continue
+				}
+				var score float64
+				switch {
+				case players[i].awesomePoints > players[j].awesomePoints:
+					score = 1
+				case players[i].awesomePoints == players[j].awesomePoints:
+					score = 0.5
+				}
+				expected := 1 / (1 + math.Pow(10, (infos[j].Rating-infos[i].Rating)/400))
+				changes[i] += ratingK * (score - expected) / float64(len(players)-1)
+			}
+		}
+
+		for i, info := range infos {
+			info.Rating += changes[i]
+			info.Games++
+		}
+		return nil
+	})
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// showRank says where a player stands this season, "!rank" for your own or "!rank nick"
+func (b *bot) showRank(channel, nick string, args []string) error {
+	if len(args) > 0 {
+		nick = kickTarget(args[0])
+	}
+
+	now := time.Now()
+	var ratings channelRatings
+	err := b.store.update(channelFile(channel, ratingsFile), &ratings, func() error {
+		return startSeason(b.store, channel, &ratings, now)
+	})
+	if err != nil {
+		return err
+	}
+
+	daysLeft := int(seasonEnd(now).Sub(now).Hours() / 24)
+	standings := ratings.standings()
+
+	key := b.users.profileKey(nick)
+	for i, info := range standings {
+		if ratings.Ratings[key] == info || (ratings.Ratings[key] == nil && strings.EqualFold(info.Nick, nick)) {
+			b.irc.Say(channel, fmt.Sprintf("%s is #%d of %d in season %s with a rating of %.0f (%d games). %d days left in the season.",
+				info.Nick, i+1, len(standings), ratings.Season, info.Rating, info.Games, daysLeft))
+			return nil
+		}
+	}
+
+	b.irc.Say(channel, fmt.Sprintf("%s hasn't finished a game in season %s yet. %d days left in the season.", nick, ratings.Season, daysLeft))
+	return nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Rebooting the Universe: spend an Awesome Point to trade in your whole hand.
+
+type rebootWindow int
+
+const (
+	// RebootBeforePlaying allows a reboot during a round you haven't played a card in yet
+	RebootBeforePlaying rebootWindow = 1 << iota
+	// RebootWhileCzar allows a reboot while you're the czar, since you aren't answering anyway
+	RebootWhileCzar
+)
+
+type rebootRules struct {
+	cost       int          // Awesome Points spent per reboot
+	maxPerGame int          // reboots allowed per player, 0 for no limit
+	windows    rebootWindow // when a reboot is allowed
+}
+
+var defaultRebootRules = rebootRules{
+	cost:    1,
+	windows: RebootBeforePlaying | RebootWhileCzar,
+}
+
+// names for the windows in rebootwhen=
+var rebootWindowNames = []struct {
+	window rebootWindow
+	name   string
+}{
+	{RebootBeforePlaying, "before"},
+	{RebootWhileCzar, "czar"},
+}
+
+// parseRebootWindows reads rebootwhen= like "before,czar"
+func parseRebootWindows(value string) (rebootWindow, error) {
+	var windows rebootWindow
+	for _, name := range strings.Split(value, ",") {
+		var found bool
+		for _, w := range rebootWindowNames {
+			if strings.EqualFold(name, w.name) {
+				windows |= w.window
+				found = true
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("rebootwhen needs to be before, czar or both, not %q", value)
+		}
+	}
+	return windows, nil
+}
+
+func (r rebootRules) String() string {
+	var windows []string
+	for _, w := range rebootWindowNames {
+		if r.windows&w.window != 0 {
+			windows = append(windows, w.name)
+		}
+	}
+	limit := "no limit"
+	if r.maxPerGame > 0 {
+		limit = fmt.Sprintf("%d per game", r.maxPerGame)
+	}
+	return fmt.Sprintf("%d points, %s, when %s", r.cost, limit, strings.Join(windows, " or "))
+}
+
+func (g *game) rebootHand(nick string) error {
+	if !g.houseRules.has(HouseRuleReboot) {
+		g.sendMsg("Rebooting the Universe isn't a house rule in this game.")
+		return nil
+	}
+
+	player := g.getPlayer(nick)
+	if player == nil {
+		return fmt.Errorf("%q isn't playing", nick)
+	}
+
+	round, err := g.getCurrentRound()
+	if err != nil {
+		g.sendMsg(fmt.Sprintf("%s, wait for the game to start before rebooting the universe.", nick))
+		return nil
+	}
+
+	if !g.canReboot(round, nick) {
+		g.sendMsg(fmt.Sprintf("%s, you can only reboot the universe between rounds.", nick))
+		return nil
+	}
+
+	if g.reboot.maxPerGame > 0 && player.reboots >= g.reboot.maxPerGame {
+		times := fmt.Sprintf("%d times", player.reboots)
+		if player.reboots == 1 {
+			times = "once"
+		}
+		g.sendMsg(fmt.Sprintf("%s, you've already rebooted the universe %s this game.", nick, times))
+		return nil
+	}
+
+	cost := fmt.Sprintf("%d Awesome Points", g.reboot.cost)
+	if g.reboot.cost == 1 {
+		cost = "1 Awesome Point"
+	}
+
+	if player.awesomePoints < g.reboot.cost {
+		g.sendMsg(fmt.Sprintf("%s, rebooting the universe costs %s. You have %d.", nick, cost, player.awesomePoints))
+		return nil
+	}
+
+	player.awesomePoints -= g.reboot.cost
+	player.reboots++
+
+	for _, c := range player.cards {
+		g.tallyCard(answerKind, c.card).Mulliganed++
+	}
+	g.discardAnswerCards(player.cards...)
+	player.cards = g.getNextAnswerCards(len(player.cards))
+
+	if g.reboot.cost == 0 {
+		g.sendMsg(fmt.Sprintf("%s rebooted the universe!", nick))
+	} else {
+		g.sendMsg(fmt.Sprintf("%s spent %s to reboot the universe! They now have %d.", nick, cost, player.awesomePoints))
+	}
+
+	if _, ok := round.players[nick]; ok && round.state == RoundPlaying {
+		g.messagePlayer(nick, fmt.Sprintf("Your new cards are: %s | Type !play # to play", formatAnswerCards(player.cards)))
+	} else {
+		g.messagePlayer(nick, fmt.Sprintf("Your new cards are: %s", formatAnswerCards(player.cards)))
+	}
+
+	return nil
+}
+
+func (g *game) canReboot(round *round, nick string) bool {
+	if g.reboot.windows&RebootWhileCzar != 0 && round.czar == nick && round.state != RoundOver {
+		return true
+	}
+
+	if g.reboot.windows&RebootBeforePlaying != 0 && round.state == RoundPlaying {
+		if _, ok := round.players[nick]; !ok {
+			return false
+		}
+		for _, c := range round.cards {
+			if c.nick == nick {
+				return false
+			}
+		}
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// when a game ends the bot writes a recap of every round as HTML and Markdown, so the funniest
+// moments can be shared after the stream.
+
+type gameRecap struct {
+	Channel       string
+	Started       time.Time
+	Ended         time.Time
+	Rounds        []roundRecap
+	Standings     []standingRecap
+	MostRounds    string // most rounds won, "nick (n)"
+	PeoplesChoice string // most People's Choice awards, "nick (n)"
+}
+
+type roundRecap struct {
+	Number        int
+	Question      string
+	Czar          string
+	Answer        string   // the winning answer filled in
+	Winners       []string // best first
+	PeoplesChoice []string
+}
+
+type standingRecap struct {
+	Nick          string
+	AwesomePoints int
+}
+
+func (r gameRecap) Duration() time.Duration {
+	return r.Ended.Sub(r.Started).Round(time.Minute)
+}
+
+// recap collects the game's highlights from its rounds
+func (g *game) recap() gameRecap {
+	r := gameRecap{
+		Channel: g.channel,
+		Started: g.gameStart,
+		Ended:   time.Now(),
+	}
+
+	wins := make(map[string]int)
+	for _, round := range g.rounds {
+		if round.state != RoundOver {
+			continue
+		}
+
+		rr := roundRecap{
+			Number:        round.number,
+			Question:      round.question.Text,
+			Czar:          round.czar,
+			PeoplesChoice: round.peoplesChoice,
+		}
+		if len(round.awards) > 0 {
+			rr.Answer = fillInAnswer(round.question, round.cards[round.awards[0].cardIndex].cards)
+		}
+		for _, award := range round.awards {
+			if len(round.awards) > 1 {
+				// Serious Business hands out points to the runners up too
+				rr.Winners = append(rr.Winners, fmt.Sprintf("%s (+%d)", award.nick, award.points))
+			} else {
+				rr.Winners = append(rr.Winners, award.nick)
+			}
+		}
+		if len(round.awards) > 0 {
+			wins[round.awards[0].nick]++
+		}
+		r.Rounds = append(r.Rounds, rr)
+	}
+
+	var mostWins, mostPeoplesChoice int
+	for _, p := range g.sortByAwesomePoints(g.scoreboard()) {
+		r.Standings = append(r.Standings, standingRecap{Nick: p.nick, AwesomePoints: p.awesomePoints})
+		if wins[p.nick] > mostWins {
+			mostWins = wins[p.nick]
+			r.MostRounds = fmt.Sprintf("%s (%d)", p.nick, mostWins)
+		}
+		if p.peoplesChoice > mostPeoplesChoice {
+			mostPeoplesChoice = p.peoplesChoice
+			r.PeoplesChoice = fmt.Sprintf("%s (%d)", p.nick, mostPeoplesChoice)
+		}
+	}
+
+	return r
+}
+
+func (r gameRecap) markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Cards Against Humanity in %s\n\n", r.Channel)
+	fmt.Fprintf(&b, "%s, %d rounds in %s.\n\n", r.Started.Format("January 2, 2006"), len(r.Rounds), r.Duration())
+
+	b.WriteString("## Final Scores\n\n")
+	for i, s := range r.Standings {
+		fmt.Fprintf(&b, "%d. %s: %d Awesome Points\n", i+1, s.Nick, s.AwesomePoints)
+	}
+	if r.MostRounds != "" {
+		fmt.Fprintf(&b, "\nMost rounds won: %s\n", r.MostRounds)
+	}
+	if r.PeoplesChoice != "" {
+		fmt.Fprintf(&b, "\nPeople's Choice: %s\n", r.PeoplesChoice)
+	}
+
+	b.WriteString("\n## Rounds\n")
+	for _, round := range r.Rounds {
+		fmt.Fprintf(&b, "\n### Round %d\n\n", round.Number)
+		fmt.Fprintf(&b, "**%s**\n\n", round.Question)
+		if round.Answer == "" {
+			b.WriteString("Nobody won.\n")
+			continue
+		}
+		fmt.Fprintf(&b, "> %s\n\n", round.Answer)
+		fmt.Fprintf(&b, "Won by %s", strings.Join(round.Winners, ", "))
+		if round.Czar != "" {
+			fmt.Fprintf(&b, ", picked by %s", round.Czar)
+		}
+		b.WriteString(".\n")
+		if len(round.PeoplesChoice) > 0 {
+			fmt.Fprintf(&b, "\nPeople's Choice: %s\n", strings.Join(round.PeoplesChoice, ", "))
+		}
+	}
+
+	return b.String()
+}
+
+var recapTemplate = template.Must(template.New("recap").Funcs(template.FuncMap{
+	"join": strings.Join,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Cards Against Humanity in {{.Channel}}</title>
+<style>
+body { background: #111; color: #eee; font-family: Helvetica, Arial, sans-serif; max-width: 700px; margin: 2em auto; }
+.question { background: #000; border: 1px solid #444; border-radius: 8px; padding: 1em; font-weight: bold; }
+.answer { background: #fff; color: #000; border-radius: 8px; padding: 1em; margin-top: 0.5em; }
+.who { color: #999; font-size: 0.9em; }
+</style>
+</head>
+<body>
+<h1>Cards Against Humanity in {{.Channel}}</h1>
+<p>{{.Started.Format "January 2, 2006"}}, {{len .Rounds}} rounds in {{.Duration}}.</p>
+<h2>Final Scores</h2>
+<ol>
+{{range .Standings}}<li>{{.Nick}}: {{.AwesomePoints}} Awesome Points</li>
+{{end}}</ol>
+{{if .MostRounds}}<p>Most rounds won: {{.MostRounds}}</p>{{end}}
+{{if .PeoplesChoice}}<p>People's Choice: {{.PeoplesChoice}}</p>{{end}}
+<h2>Rounds</h2>
+{{range .Rounds}}<h3>Round {{.Number}}</h3>
+<div class="question">{{.Question}}</div>
+{{if .Answer}}<div class="answer">{{.Answer}}</div>
+<p class="who">Won by {{join .Winners ", "}}{{if .Czar}}, picked by {{.Czar}}{{end}}.{{if .PeoplesChoice}} People's Choice: {{join .PeoplesChoice ", "}}{{end}}</p>
+{{else}}<p class="who">Nobody won.</p>
+{{end}}{{end}}</body>
+</html>
+`))
+
+func (r gameRecap) html() (string, error) {
+	var b bytes.Buffer
+	if err := recapTemplate.Execute(&b, r); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// save writes the recap to dir as HTML and Markdown and returns the file name without the extension
+func (r gameRecap) save(dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	name := filepath.Join(dir, fmt.Sprintf("%s-%s", strings.TrimPrefix(r.Channel, "#"), r.Ended.Format("2006-01-02-150405")))
+
+	html, err := r.html()
+	if err != nil {
+		return "", err
+	}
+	if err = ioutil.WriteFile(name+".html", []byte(html), 0644); err != nil {
+		return "", err
+	}
+	if err = ioutil.WriteFile(name+".md", []byte(r.markdown()), 0644); err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
+
+// summary is the recap in one line of chat
+func (r gameRecap) summary() string {
+	msg := fmt.Sprintf("Recap: %d rounds in %s.", len(r.Rounds), r.Duration())
+	if r.MostRounds != "" {
+		msg += fmt.Sprintf(" Most rounds won: %s.", r.MostRounds)
+	}
+	if r.PeoplesChoice != "" {
+		msg += fmt.Sprintf(" People's Choice: %s.", r.PeoplesChoice)
+	}
+	return msg
+}
+
+// postRecap saves the finished game's recap and sums it up in chat
+func (b *bot) postRecap(channel string, game *game) {
+	game.stateMtx.Lock()
+	recap := game.recap()
+	game.stateMtx.Unlock()
+
+	if len(recap.Rounds) == 0 {
+		return
+	}
+
+	name, err := recap.save(b.botCfg.recapDir)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	log.Printf("recap saved to %s.html and %s.md\n", name, name)
+
+	b.irc.Say(channel, recap.summary())
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// scoringMode decides how the answers on the table turn into Awesome Points. God Is Dead
+// doesn't use one, the vote decides the round.
+type scoringMode interface {
+	// start posts the judging instructions once the answers are shown. it returns the awards
+	// if there's nothing to judge.
+	start(g *game, round *round) []roundAward
+	// judge handles a pick. it returns the awards once the round has been decided,
+	// or nil while judging is still going.
+	judge(g *game, round *round, nick string, picks []int) ([]roundAward, error)
+}
+
+type roundAward struct {
+	nick      string
+	cardIndex int
+	points    int
+}
+
+func newScoringMode(rules houseRules) scoringMode {
+	switch {
+	case rules.has(HouseRuleSurvival):
+		return &survivalScoring{}
+	case rules.has(HouseRuleSeriousBusiness):
+		return &seriousBusinessScoring{points: []int{3, 2, 1}}
+	default:
+		return &czarScoring{}
+	}
+}
+
+func checkPicks(g *game, round *round, nick string, picks []int) bool {
+	for i, pick := range picks {
+		if pick >= len(round.cards) {
+			g.sendMsg(fmt.Sprintf("%s, pick a number 0-%d", nick, len(round.cards)-1))
+			return false
+		}
+		for _, other := range picks[:i] {
+			if other == pick {
+				g.sendMsg(fmt.Sprintf("%s, pick different answers", nick))
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// czarScoring is the regular game, the czar picks one winner for one Awesome Point
+type czarScoring struct{}
+
+func (s *czarScoring) start(g *game, round *round) []roundAward {
+	g.sendMsg(fmt.Sprintf("%s, pick the winner by typing !winner #", round.czar))
+	return nil
+}
+
+func (s *czarScoring) judge(g *game, round *round, nick string, picks []int) ([]roundAward, error) {
+	if round.czar != nick {
+		return nil, fmt.Errorf("%q is not the card czar for this round", nick)
+	}
+
+	if len(picks) != 1 {
+		g.sendMsg(fmt.Sprintf("%s, pick one winner", nick))
+		return nil, nil
+	}
+
+	if !checkPicks(g, round, nick, picks) {
+		return nil, nil
+	}
+
+	return []roundAward{{nick: round.cards[picks[0]].nick, cardIndex: picks[0], points: 1}}, nil
+}
+
+// seriousBusinessScoring has the czar rank the top answers, !winner 2 0 4 gives 3, 2 and 1 Awesome Points
+type seriousBusinessScoring struct {
+	points []int
+}
+
+func (s *seriousBusinessScoring) ranks(round *round) int {
+	if len(round.cards) < len(s.points) {
+		return len(round.cards)
+	}
+	return len(s.points)
+}
+
+func (s *seriousBusinessScoring) start(g *game, round *round) []roundAward {
+	n := s.ranks(round)
+	g.sendMsg(fmt.Sprintf("%s, rank the top %d answers, best first, by typing !winner%s", round.czar, n, strings.Repeat(" #", n)))
+	return nil
+}
+
+func (s *seriousBusinessScoring) judge(g *game, round *round, nick string, picks []int) ([]roundAward, error) {
+	if round.czar != nick {
+		return nil, fmt.Errorf("%q is not the card czar for this round", nick)
+	}
+
+	n := s.ranks(round)
+	if len(picks) != n {
+		g.sendMsg(fmt.Sprintf("%s, rank the top %d answers, best first", nick, n))
+		return nil, nil
+	}
+
+	if !checkPicks(g, round, nick, picks) {
+		return nil, nil
+	}
+
+	var awards []roundAward
+	for i, pick := range picks {
+		awards = append(awards, roundAward{nick: round.cards[pick].nick, cardIndex: pick, points: s.points[i]})
+	}
+	return awards, nil
+}
+
+// survivalScoring has everyone take turns eliminating answers with !eliminate #, starting with
+// the czar. the last answer standing wins an Awesome Point.
+type survivalScoring struct{}
+
+func (s *survivalScoring) start(g *game, round *round) []roundAward {
+	round.eliminated = make(map[int]bool)
+	// the czar goes first, then everyone else in czar order
+	round.eliminators = g.czars.upcoming()
+
+	if len(round.cards) == 1 {
+		g.sendMsg("Survival of the Fittest! There's only one answer, so it survives.")
+		return []roundAward{{nick: round.cards[0].nick, cardIndex: 0, points: 1}}
+	}
+
+	g.sendMsg(fmt.Sprintf("Survival of the Fittest! %s, eliminate the worst answer by typing !eliminate #", s.nextEliminator(g, round)))
+	return nil
+}
+
+// nextEliminator takes turns through the eliminators, passing over anyone who's gone
+func (s *survivalScoring) nextEliminator(g *game, round *round) string {
+	var present []string
+	for _, nick := range round.eliminators {
+		if p := g.getPlayer(nick); p != nil && !p.suspended && !p.afk {
+			present = append(present, nick)
+		}
+	}
+	if len(present) == 0 {
+		return ""
+	}
+	return present[len(round.eliminated)%len(present)]
+}
+
+func (s *survivalScoring) judge(g *game, round *round, nick string, picks []int) ([]roundAward, error) {
+	if s.nextEliminator(g, round) != nick {
+		return nil, fmt.Errorf("it's not %q's turn to eliminate an answer", nick)
+	}
+
+	if len(picks) != 1 {
+		g.sendMsg(fmt.Sprintf("%s, eliminate one answer", nick))
+		return nil, nil
+	}
+
+	if !checkPicks(g, round, nick, picks) {
+		return nil, nil
+	}
+
+	if round.eliminated[picks[0]] {
+		g.sendMsg(fmt.Sprintf("%s, [%d] is already gone. Pick another one.", nick, picks[0]))
+		return nil, nil
+	}
+
+	// the last answer standing can't be eliminated
+	if len(round.cards)-len(round.eliminated) <= 1 {
+		return nil, errors.New("there's only one answer left")
+	}
+
+	round.eliminated[picks[0]] = true
+
+	left := len(round.cards) - len(round.eliminated)
+	if left > 1 {
+		g.sendMsg(fmt.Sprintf("%s eliminated [%d]. %d answers left. %s, your turn: !eliminate #", nick, picks[0], left, s.nextEliminator(g, round)))
+		return nil, nil
+	}
+
+	for i, c := range round.cards {
+		if !round.eliminated[i] {
+			g.sendMsg(fmt.Sprintf("%s eliminated [%d]. [%d] is the last answer standing!", nick, picks[0], i))
+			return []roundAward{{nick: c.nick, cardIndex: i, points: 1}}, nil
+		}
+	}
+
+	return nil, errors.New("every answer was eliminated")
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// a game has maxPlayers seats. anyone else waits on the waitlist and gets seated between rounds,
+// or watches as a spectator. spectators aren't dealt cards and are never czar.
+
+func removeNick(nicks []string, nick string) ([]string, bool) {
+	for i, n := range nicks {
+		if n == nick {
+			return append(nicks[:i], nicks[i+1:]...), true
+		}
+	}
+	return nicks, false
+}
+
+func containsNick(nicks []string, nick string) bool {
+	for _, n := range nicks {
+		if n == nick {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *game) gameIsFull() bool {
+	return g.maxPlayers > 0 && len(g.players) >= g.maxPlayers
+}
+
+func (g *game) addToWaitlist(nick string) {
+	if containsNick(g.waitlist, nick) {
+		g.sendMsg(fmt.Sprintf("%s, you're already on the waitlist. #%d in line.", nick, g.waitlistPosition(nick)))
+		return
+	}
+
+	g.waitlist = append(g.waitlist, nick)
+	g.sendMsg(fmt.Sprintf("Sorry %s, the game's full (%d players). You're #%d on the waitlist.", nick, g.maxPlayers, len(g.waitlist)))
+}
+
+func (g *game) waitlistPosition(nick string) int {
+	for i, n := range g.waitlist {
+		if n == nick {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// seatWaitlist fills any open seats from the waitlist. call it between rounds.
+func (g *game) seatWaitlist() {
+	for len(g.waitlist) > 0 && !g.gameIsFull() {
+		nick := g.waitlist[0]
+		g.waitlist = g.waitlist[1:]
+		g.join(nick)
+	}
+}
+
+func (g *game) spectate(nick string) error {
+	if containsNick(g.spectators, nick) {
+		g.sendMsg(fmt.Sprintf("%s, you're already spectating.", nick))
+		return nil
+	}
+
+	if containsNick(g.kicked, nick) {
+		g.sendMsg(fmt.Sprintf("%s, you were kicked out of this game.", nick))
+		return nil
+	}
+
+	if g.getPlayer(nick) != nil {
+		if g.lobby == nil {
+			g.sendMsg(fmt.Sprintf("%s, the game has started. You can only switch to spectating in the lobby.", nick))
+			return nil
+		}
+		g.removePlayer(nick)
+		g.czars.remove(nick)
+		delete(g.lobby.ready, nick)
+		g.seatWaitlist()
+	}
+
+	g.waitlist, _ = removeNick(g.waitlist, nick)
+	g.spectators = append(g.spectators, nick)
+	g.sendMsg(fmt.Sprintf("%s is spectating. !status to see what's going on.", nick))
+
+	if g.lobby != nil {
+		g.lobbyPlayersChanged()
+	}
+
+	return nil
+}
+
+// status is whispered to anyone in the game, players and spectators alike
+func (g *game) status(nick string) error {
+	if g.getPlayer(nick) == nil && !containsNick(g.spectators, nick) {
+		g.sendMsg(fmt.Sprintf("%s, !join or !spectate to follow the game.", nick))
+		return nil
+	}
+
+	var status string
+	if g.lobby != nil {
+		var nicks []string
+		for _, p := range g.players {
+			nicks = append(nicks, p.nick)
+		}
+		status = fmt.Sprintf("Waiting to start. Players (%d/%d): %s", len(g.players), g.minPlayers, strings.Join(nicks, ", "))
+	} else {
+		round, err := g.getCurrentRound()
+		if err != nil {
+			return err
+		}
+
+		status = fmt.Sprintf("Round %d: %s", round.number, round.question.Text)
+		switch round.state {
+		case RoundPlaying:
+			var waitingOn []string
+			for n := range round.players {
+				var played bool
+				for _, c := range round.cards {
+					if c.nick == n {
+						played = true
+						break
+					}
+				}
+				if !played {
+					waitingOn = append(waitingOn, n)
+				}
+			}
+			status += fmt.Sprintf(" | Waiting on: %s", strings.Join(waitingOn, ", "))
+		case RoundCzar:
+			if round.czar != "" {
+				status += fmt.Sprintf(" | Waiting on the czar, %s", round.czar)
+			} else {
+				status += " | Voting"
+			}
+		}
+
+		var scores []string
+		for _, p := range g.sortByAwesomePoints(g.scoreboard()) {
+			scores = append(scores, fmt.Sprintf("%s: %d", p.nick, p.awesomePoints))
+		}
+		status += fmt.Sprintf(" | Awesome Points: %s", strings.Join(scores, ", "))
+	}
+
+	if len(g.waitlist) > 0 {
+		status += fmt.Sprintf(" | Waitlist: %s", strings.Join(g.waitlist, ", "))
+	}
+	if len(g.spectators) > 0 {
+		status += fmt.Sprintf(" | Spectators: %d", len(g.spectators))
+	}
+
+	g.messagePlayer(nick, status)
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// twitch drops messages over 500 characters and IRC lines can't be more than 512 bytes, so long
+// messages go out in parts. parts break between words, and card lists break between cards.
+const (
+	maxMessageRunes = 500
+	maxLineBytes    = 512 - len("\r\n")
+
+	// ends a part that's continued, and starts the part that continues it
+	continuedMarker = "…"
+)
+
+// cardBreak is where a card list like "[0] text [1] text" can break, just before the "[#]"
+var cardBreak = regexp.MustCompile(` \[\d+\] `)
+
+// splitMessage splits message into parts of at most maxBytes bytes and maxRunes runes, markers
+// included
+func splitMessage(message string, maxBytes, maxRunes int) []string {
+	var parts []string
+	for {
+		prefix := ""
+		if len(parts) > 0 {
+			prefix = continuedMarker
+		}
+		if len(prefix+message) <= maxBytes && utf8.RuneCountInString(prefix+message) <= maxRunes {
+			return append(parts, prefix+message)
+		}
+
+		cut := cutMessage(message, maxBytes-len(prefix+continuedMarker), maxRunes-utf8.RuneCountInString(prefix+continuedMarker))
+		parts = append(parts, prefix+strings.TrimRight(message[:cut], " ")+continuedMarker)
+		message = strings.TrimLeft(message[cut:], " ")
+		if message == "" {
+			// only spaces were left over
+			parts[len(parts)-1] = strings.TrimSuffix(parts[len(parts)-1], continuedMarker)
+			return parts
+		}
+	}
+}
+
+// cutMessage is where to end a part of message that can only be maxBytes bytes and maxRunes runes.
+// a card break is best, then a space, then anywhere that doesn't split a character.
+func cutMessage(message string, maxBytes, maxRunes int) int {
+	// the longest start of message that fits
+	limit, runes := 0, 0
+	for i := range message {
+		if i > maxBytes || runes > maxRunes {
+			break
+		}
+		limit = i
+		runes++
+	}
+	if limit == 0 {
+		// not even one character fits, take one anyway so there's progress
+		_, size := utf8.DecodeRuneInString(message)
+		return size
+	}
+
+	// the break has to leave the whole "[#]" for the next part
+	if breaks := cardBreak.FindAllStringIndex(message[:limit+1], -1); len(breaks) > 0 {
+		if last := breaks[len(breaks)-1][0]; last > 0 {
+			return last
+		}
+	}
+	if space := strings.LastIndex(message[:limit+1], " "); space > 0 {
+		return space
+	}
+	return limit
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// dataStore keeps the bot's files in one directory. everything is small enough to be a
+// JSON file read and written whole.
+type dataStore struct {
+	dir string
+	mtx sync.Mutex
+}
+
+func newDataStore(dir string) (*dataStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &dataStore{dir: dir}, nil
+}
+
+// channelFile is the name of a file that belongs to a single channel
+func channelFile(channel, name string) string {
+	return filepath.Join(strings.TrimPrefix(channel, "#"), name)
+}
+
+// load reads a JSON file into v. a missing file leaves v alone.
+func (s *dataStore) load(name string, v interface{}) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.read(name, v)
+}
+
+func (s *dataStore) save(name string, v interface{}) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.write(name, v)
+}
+
+// update loads a JSON file into v, lets fn change it, and saves it
+func (s *dataStore) update(name string, v interface{}, fn func() error) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if err := s.read(name, v); err != nil {
+		return err
+	}
+	if err := fn(); err != nil {
+		return err
+	}
+	return s.write(name, v)
+}
+
+func (s *dataStore) read(name string, v interface{}) error {
+	b, err := ioutil.ReadFile(filepath.Join(s.dir, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
+
+func (s *dataStore) write(name string, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fileName := filepath.Join(s.dir, name)
+	if err = os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+		return err
+	}
+
+	// write to a temp file first so a crash doesn't leave half a file behind
+	tmpFileName := fileName + ".tmp"
+	if err = ioutil.WriteFile(tmpFileName, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpFileName, fileName)
+}
+
+// loadLines reads a plain text file, one entry per line. blank lines and lines starting
+// with # are skipped.
+func (s *dataStore) loadLines(name string) ([]string, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	f, err := os.Open(filepath.Join(s.dir, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, scanner.Err()
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// czars fat-finger !winner. once a round's decided there's a few seconds to !undo it before
+// the next round starts. the czar can undo their own pick, moderators can undo anything, even
+// the last round after the game's moved on.
+
+func (g *game) openUndoWindow(round *round) {
+	round.undoable = true
+	round.judgings++
+	number, judgings := round.number, round.judgings
+
+	g.sendMsg(fmt.Sprintf("Wrong pick? !undo in the next %s.", g.confirmWindow))
+
+	time.AfterFunc(g.confirmWindow, func() {
+		g.stateMtx.Lock()
+		defer g.stateMtx.Unlock()
+
+		current, err := g.getCurrentRound()
+		if err != nil || current.number != number || !current.undoable || current.judgings != judgings {
+			return
+		}
+
+		current.undoable = false
+		g.finishRound(current)
+	})
+}
+
+// undo takes back the round's Awesome Points and goes back to judging
+func (g *game) undo(nick string, isModerator bool) error {
+	round, err := g.getCurrentRound()
+	if err != nil {
+		return err
+	}
+
+	if !round.undoable {
+		if isModerator {
+			return g.takeBack(nick)
+		}
+		g.sendMsg(fmt.Sprintf("%s, there's nothing to undo.", nick))
+		return nil
+	}
+
+	if nick != round.czar && !isModerator {
+		return fmt.Errorf("%q can't undo round %d", nick, round.number)
+	}
+
+	for _, player := range g.scoreboard() {
+		if points, ok := round.pointsBefore[player.nick]; ok {
+			player.awesomePoints = points
+		}
+	}
+
+	round.undoable = false
+	round.awards = nil
+	round.pointsBefore = nil
+	round.state = RoundCzar
+
+	g.sendMsg(fmt.Sprintf("%s undid the pick. Awesome Points are back where they were.", nick))
+	g.showAnswers(round)
+	if awards := g.scoring.start(g, round); awards != nil {
+		return g.endRound(round, awards)
+	}
+	g.startRoundTimer(round, RoundCzar, g.czarTimeout)
+
+	return nil
+}
+
+// takeBack undoes the last round after the next one started, when there was no undo window or
+// it closed. it's too late to pick again, so the round's Awesome Points just go away. its quote,
+// write-ins and card stats haven't been kept yet, so they go too.
+func (g *game) takeBack(nick string) error {
+	g.roundsMtx.RLock()
+	var last *round
+	if len(g.rounds) > 1 {
+		last = &g.rounds[len(g.rounds)-2]
+	}
+	g.roundsMtx.RUnlock()
+
+	if last == nil || last.pointsWon == nil {
+		g.sendMsg(fmt.Sprintf("%s, there's nothing to undo.", nick))
+		return nil
+	}
+
+	for _, player := range g.scoreboard() {
+		player.awesomePoints -= last.pointsWon[player.nick]
+		if player.awesomePoints < 0 {
+			player.awesomePoints = 0
+		}
+	}
+	last.awards = nil
+	last.pointsWon = nil
+
+	g.sendMsg(fmt.Sprintf("%s took back Round %d. Nobody wins it, and the Awesome Points it gave out are gone.", nick, last.number))
+
+	// if that round is what got someone to the Happy Ending and nobody's there anymore, this is
+	// just another round. the Happy Ending waits until someone wins for real.
+	if g.happyEnding && g.sortByAwesomePoints(g.scoreboard())[0].awesomePoints < g.awesomePointsToWin {
+		g.happyEnding = false
+		g.sendMsg("No winner after all, so this isn't the last round.")
+	}
+
+	return nil
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"testing"
+)
+
+// playAll has everyone in the round play their first cards
+func playAll(g *game, round *round) {
+	for nick, p := range round.players {
+		var picks []int
+		for i := 0; i < round.question.NumAnswers; i++ {
+			picks = append(picks, i)
+		}
+		var writeIn string
+		if hasBlankCard(p.cards[:round.question.NumAnswers]) {
+			writeIn = "a write-in"
+		}
+		g.play(nick, picks, writeIn)
+	}
+}
+
+// pickWinner has the czar pick nick's answer
+func pickWinner(t *testing.T, g *game, round *round, nick string) {
+	for i, c := range round.cards {
+		if c.nick == nick {
+			if err := g.winner(round.czar, []int{i}); err != nil {
+				t.Fatal(err)
+			}
+			return
+		}
+	}
+	t.Fatalf("%s didn't play in round %d", nick, round.number)
+}
+
+// someoneElse is a player in the round other than the ones given
+func someoneElse(round *round, not ...string) string {
+	for nick := range round.players {
+		if !containsNick(not, nick) {
+			return nick
+		}
+	}
+	return ""
+}
+
+func TestTakeBack(t *testing.T) {
+	g := newTestGame(t, "undo=0 points=5", []string{"alice", "bob", "carol", "dave"})
+	g.stateMtx.Lock()
+	defer g.stateMtx.Unlock()
+
+	round, _ := g.getCurrentRound()
+	winner := someoneElse(round)
+	// the winner's answer is a write-in
+	g.getPlayer(winner).cards[0] = newBlankCards(1)[0]
+	question := round.question
+	playAll(g, round)
+	pickWinner(t, g, round, winner)
+
+	if points := g.getPlayer(winner).awesomePoints; points != 1 {
+		t.Fatalf("%s has %d Awesome Points, want 1", winner, points)
+	}
+
+	g.undo(someoneElse(round, winner), false)
+	if points := g.getPlayer(winner).awesomePoints; points != 1 {
+		t.Fatal("a player who isn't a moderator took back the round")
+	}
+	if err := g.undo("mod", true); err != nil {
+		t.Fatal(err)
+	}
+	if points := g.getPlayer(winner).awesomePoints; points != 0 {
+		t.Errorf("%s still has %d Awesome Points", winner, points)
+	}
+
+	// nothing's kept until the round after it is over
+	round, _ = g.getCurrentRound()
+	g.czarTimedOut(round)
+
+	var quotes []quote
+	if err := g.store.load(channelFile(g.channel, quotesFile), &quotes); err != nil {
+		t.Fatal(err)
+	}
+	if len(quotes) != 0 {
+		t.Errorf("the taken back round is in the Hall of Fame: %v", quotes)
+	}
+	var writeIns []savedWriteIn
+	if err := g.store.load(channelFile(g.channel, writeInsFile), &writeIns); err != nil {
+		t.Fatal(err)
+	}
+	if len(writeIns) != 0 {
+		t.Errorf("the taken back write-in was saved: %v", writeIns)
+	}
+	if flat := g.tallyCard(questionKind, question.card).Flat; flat != 1 {
+		t.Errorf("the taken back round's question is flat %d times, want 1", flat)
+	}
+
+	if err := g.undo("mod", true); err != nil {
+		t.Fatal(err)
+	}
+	if len(g.rounds) != 3 {
+		t.Errorf("taking back a round without a winner moved the game on")
+	}
+
+	// a round nobody takes back is kept once the round after it is over
+	round, _ = g.getCurrentRound()
+	playAll(g, round)
+	pickWinner(t, g, round, someoneElse(round))
+	round, _ = g.getCurrentRound()
+	g.czarTimedOut(round)
+	if err := g.store.load(channelFile(g.channel, quotesFile), &quotes); err != nil {
+		t.Fatal(err)
+	}
+	if len(quotes) != 1 {
+		t.Errorf("%d quotes in the Hall of Fame, want 1", len(quotes))
+	}
+}
+
+func TestTakeBackHappyEnding(t *testing.T) {
+	g := newTestGame(t, "undo=0 points=1 rules=happyending", []string{"alice", "bob", "carol", "dave"})
+	g.stateMtx.Lock()
+	defer g.stateMtx.Unlock()
+
+	round, _ := g.getCurrentRound()
+	playAll(g, round)
+	pickWinner(t, g, round, someoneElse(round))
+	if !g.happyEnding {
+		t.Fatal("no Happy Ending after someone won")
+	}
+
+	if err := g.undo("mod", true); err != nil {
+		t.Fatal(err)
+	}
+	if g.happyEnding {
+		t.Fatal("still a Happy Ending after the winning round was taken back")
+	}
+
+	// the haiku round goes on as a regular round, whoever wins it gets the Happy Ending
+	round, _ = g.getCurrentRound()
+	playAll(g, round)
+	pickWinner(t, g, round, someoneElse(round))
+	if isOver(g) || !g.happyEnding {
+		t.Error("the haiku round after a take back ended the game")
+	}
+}
+
+func TestTakeBackSuddenDeath(t *testing.T) {
+	g := newTestGame(t, "undo=0 points=2", []string{"alice", "bob", "carol", "dave"})
+	g.stateMtx.Lock()
+	defer g.stateMtx.Unlock()
+
+	round, _ := g.getCurrentRound()
+	leader := someoneElse(round)
+	tied := someoneElse(round, leader)
+	g.getPlayer(leader).awesomePoints = 2
+	g.getPlayer(tied).awesomePoints = 1
+	playAll(g, round)
+	pickWinner(t, g, round, tied)
+	if isOver(g) {
+		t.Fatal("the game ended with first place tied")
+	}
+
+	if err := g.undo("mod", true); err != nil {
+		t.Fatal(err)
+	}
+
+	// without the tie the leader wins once this round is over
+	round, _ = g.getCurrentRound()
+	g.czarTimedOut(round)
+	if !isOver(g) {
+		t.Error("the game went on after the tie was taken back")
+	}
+
+	var quotes []quote
+	if err := g.store.load(channelFile(g.channel, quotesFile), &quotes); err != nil {
+		t.Fatal(err)
+	}
+	if len(quotes) != 0 {
+		t.Errorf("the taken back round is in the Hall of Fame: %v", quotes)
+	}
+}
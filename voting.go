@@ -0,0 +1,204 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// God Is Dead: there's no czar. players (and optionally viewers) vote on the answers.
+// in regular games viewers vote too, for People's Choice.
+
+type tieBreak int
+
+const (
+	TieBreakRandom   tieBreak = iota // one of the tied answers wins at random
+	TieBreakShared                   // every tied answer gets an Awesome Point
+	TieBreakNoWinner                 // nobody gets a point
+)
+
+type votingRules struct {
+	window       time.Duration // how long chat has to vote
+	viewers      bool          // let people who aren't playing vote
+	playerWeight int
+	viewerWeight int
+	tieBreak     tieBreak
+}
+
+var tieBreakNames = []string{"random", "shared", "none"}
+
+func (t tieBreak) String() string {
+	return tieBreakNames[t]
+}
+
+func parseTieBreak(name string) (tieBreak, error) {
+	for i, n := range tieBreakNames {
+		if strings.EqualFold(name, n) {
+			return tieBreak(i), nil
+		}
+	}
+	return TieBreakRandom, fmt.Errorf("tiebreak needs to be %s, not %q", strings.Join(tieBreakNames, ", "), name)
+}
+
+func (v votingRules) String() string {
+	if !v.viewers {
+		return fmt.Sprintf("%s, players only, ties %s", v.window, v.tieBreak)
+	}
+	return fmt.Sprintf("%s, players count %dx viewers %dx, ties %s", v.window, v.playerWeight, v.viewerWeight, v.tieBreak)
+}
+
+var defaultVotingRules = votingRules{
+	window:       60 * time.Second,
+	viewers:      true,
+	playerWeight: 3,
+	viewerWeight: 1,
+	tieBreak:     TieBreakRandom,
+}
+
+func (g *game) startVoting(round *round) {
+	round.votes = make(map[string]int)
+
+	if g.voting.viewers {
+		g.sendMsg(fmt.Sprintf("Everyone vote for the best answer by typing !vote #. Voting closes in %s.", g.voting.window))
+	} else {
+		g.sendMsg(fmt.Sprintf("Players, vote for the best answer by typing !vote #. Voting closes in %s.", g.voting.window))
+	}
+
+	number := round.number
+	time.AfterFunc(g.voting.window, func() {
+		g.stateMtx.Lock()
+		defer g.stateMtx.Unlock()
+
+		round, err := g.getCurrentRound()
+		if err != nil || round.number != number || round.state != RoundCzar {
+			// voting already closed
+			return
+		}
+
+		g.closeVoting(round)
+	})
+}
+
+func (g *game) vote(nick string, cardIndex int) error {
+	round, err := g.getCurrentRound()
+	if err != nil {
+		return err
+	}
+
+	if round.state != RoundCzar || round.votes == nil {
+		return errors.New("voting isn't open")
+	}
+
+	godIsDead := g.houseRules.has(HouseRuleGodIsDead)
+	isPlayer := g.getPlayer(nick) != nil
+	if !godIsDead && isPlayer {
+		// the czar's pick is the players' business, People's Choice is for the audience
+		return fmt.Errorf("%q is playing, only viewers vote for People's Choice", nick)
+	}
+	if !isPlayer && !g.voting.viewers {
+		return fmt.Errorf("%q isn't playing and viewers can't vote", nick)
+	}
+
+	if cardIndex >= len(round.cards) {
+		g.sendMsg(fmt.Sprintf("%s, pick a number 0-%d", nick, len(round.cards)-1))
+		return nil
+	}
+
+	if round.cards[cardIndex].nick == nick {
+		g.sendMsg(fmt.Sprintf("%s, nice try. You can't vote for your own answer.", nick))
+		return nil
+	}
+
+	round.votes[nick] = cardIndex
+
+	// no need to wait out the clock when only players are voting and they're all in
+	if godIsDead && !g.voting.viewers && len(round.votes) >= len(round.players) {
+		g.closeVoting(round)
+	}
+
+	return nil
+}
+
+// tallyVotes returns the weighted score for each answer and the indexes of the answers with the best score
+func (g *game) tallyVotes(round *round, weight func(voter string) int) ([]int, []int) {
+	scores := make([]int, len(round.cards))
+	for voter, cardIndex := range round.votes {
+		scores[cardIndex] += weight(voter)
+	}
+
+	var best int
+	var tied []int
+	for i, score := range scores {
+		if score > best {
+			best = score
+			tied = []int{i}
+		} else if score == best && best > 0 {
+			tied = append(tied, i)
+		}
+	}
+
+	return scores, tied
+}
+
+func (g *game) closeVoting(round *round) error {
+	_, tied := g.tallyVotes(round, func(voter string) int {
+		if g.getPlayer(voter) != nil {
+			return g.voting.playerWeight
+		}
+		return g.voting.viewerWeight
+	})
+
+	g.sendMsg(fmt.Sprintf("Voting is closed! %d votes were cast.", len(round.votes)))
+
+	if len(tied) == 0 {
+		g.sendMsg("Nobody voted. Really?")
+		return g.endRound(round, nil)
+	}
+
+	if len(tied) > 1 {
+		var nums []string
+		for _, i := range tied {
+			nums = append(nums, fmt.Sprintf("[%d]", i))
+		}
+		switch g.voting.tieBreak {
+		case TieBreakRandom:
+			winner := tied[rand.Intn(len(tied))]
+			g.sendMsg(fmt.Sprintf("It's a tie between %s! Flipping a coin... [%d] wins.", strings.Join(nums, ", "), winner))
+			tied = []int{winner}
+		case TieBreakShared:
+			g.sendMsg(fmt.Sprintf("It's a tie between %s! Everybody wins.", strings.Join(nums, ", ")))
+		case TieBreakNoWinner:
+			g.sendMsg(fmt.Sprintf("It's a tie between %s! Nobody wins.", strings.Join(nums, ", ")))
+			return g.endRound(round, nil)
+		}
+	}
+
+	var awards []roundAward
+	for _, i := range tied {
+		awards = append(awards, roundAward{nick: round.cards[i].nick, cardIndex: i, points: 1})
+	}
+
+	return g.endRound(round, awards)
+}
+
+// awardPeoplesChoice announces the audience's favorite answer in a czar game. it's bragging rights
+// only, People's Choice doesn't earn Awesome Points.
+func (g *game) awardPeoplesChoice(round *round) {
+	if len(round.votes) == 0 {
+		return
+	}
+
+	scores, favorites := g.tallyVotes(round, func(string) int { return 1 })
+	for _, i := range favorites {
+		nick := round.cards[i].nick
+		round.peoplesChoice = append(round.peoplesChoice, nick)
+		for _, player := range g.scoreboard() {
+			if player.nick == nick {
+				player.peoplesChoice++
+			}
+		}
+		g.sendMsg(fmt.Sprintf("People's Choice goes to %s with %d votes for [%d]!", nick, scores[i], i))
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"time"
+	"unicode/utf8"
+)
+
+// blank cards get mixed into the answer pile. whoever plays one writes their own answer.
+
+const (
+	blankCardText = "(blank card)"
+	// blank cards get IDs well clear of the real deck, and of the haiku card
+	blankCardFirstID = -1000
+	// winning write-ins for a channel, suggestions for its custom deck
+	writeInsFile = "writeins.json"
+	// one word or phrase per line, write-ins containing any of them are rejected. there's one in
+	// the data dir and one for each channel. a trailing * matches anything starting with it.
+	writeInBlocklistFile = "writein-blocklist.txt"
+)
+
+type writeInRules struct {
+	blanks    int // blank cards mixed into the answer pile
+	maxLength int
+	blocklist *regexp.Regexp // nil if nothing's blocked
+}
+
+var defaultWriteInRules = writeInRules{
+	blanks:    30,
+	maxLength: 100,
+}
+
+type savedWriteIn struct {
+	Text     string    `json:"text"`
+	Nick     string    `json:"nick"`
+	Question string    `json:"question"`
+	Round    int       `json:"round"`
+	Date     time.Time `json:"date"`
+}
+
+func newBlankCards(count int) []answerCard {
+	var cards []answerCard
+	for i := 0; i < count; i++ {
+		cards = append(cards, answerCard{card: card{ID: blankCardFirstID - i, Text: blankCardText}, blank: true})
+	}
+	return cards
+}
+
+func hasBlankCard(cards []answerCard) bool {
+	for _, c := range cards {
+		if c.blank {
+			return true
+		}
+	}
+	return false
+}
+
+// checkWriteIn returns a reason the text can't go on a card, or "" if it's fine
+func (g *game) checkWriteIn(text string) string {
+	if utf8.RuneCountInString(text) > g.writeIns.maxLength {
+		return fmt.Sprintf("keep it under %d characters", g.writeIns.maxLength)
+	}
+
+	if g.writeIns.blocklist != nil && g.writeIns.blocklist.MatchString(text) {
+		return "not on this channel you don't"
+	}
+
+	return ""
+}
+
+// saveWinningWriteIns keeps any write-ins that won points so the channel can add them to its deck
+func (g *game) saveWinningWriteIns(round *round) {
+	if g.store == nil {
+		return
+	}
+
+	var winners []savedWriteIn
+	for _, award := range round.awards {
+		for _, c := range round.cards[award.cardIndex].cards {
+			if c.blank {
+				winners = append(winners, savedWriteIn{
+					Text:     c.Text,
+					Nick:     award.nick,
+					Question: round.question.Text,
+					Round:    round.number,
+					Date:     time.Now(),
+				})
+			}
+		}
+	}
+
+	if len(winners) == 0 {
+		return
+	}
+
+	var writeIns []savedWriteIn
+	err := g.store.update(channelFile(g.channel, writeInsFile), &writeIns, func() error {
+		writeIns = append(writeIns, winners...)
+		return nil
+	})
+	if err != nil {
+		log.Println(err)
+	}
+}
@@ -0,0 +1,22 @@
+package main
+
+import "testing"
+
+func TestCheckWriteIn(t *testing.T) {
+	g := &game{writeIns: defaultWriteInRules}
+	g.writeIns.blocklist = termsRegexp([]string{"ass", "frick*", "pickle rick"})
+
+	for text, ok := range map[string]bool{
+		"a first class ticket":     true,
+		"touching grass":           true,
+		"a sad ass":                false,
+		"Frickin' laser beams":     false,
+		"PICKLE RICK":              false,
+		"a pickle, Rick":           true,
+		"somebody's grandpa's ASS": false,
+	} {
+		if reason := g.checkWriteIn(text); (reason == "") != ok {
+			t.Errorf("%q: got %q", text, reason)
+		}
+	}
+}